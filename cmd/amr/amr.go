@@ -6,6 +6,8 @@ import (
 	"os"
 	"strings"
 
+	"github.com/chanzuckerberg/czid-cli/cmd/uploadFlags"
+	"github.com/chanzuckerberg/czid-cli/pkg/czid"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -13,7 +15,9 @@ import (
 var projectName string
 var stringMetadata map[string]string
 var metadataCSVPath string
+var cramReference string
 var disableBuffer bool
+var flowOptions czid.UploadFlowOptions
 
 
 // AmrCmd represents the Amr command
@@ -30,10 +34,14 @@ var AmrCmd = &cobra.Command{
 }
 
 func loadSharedFlags(c *cobra.Command) {
-	c.Flags().StringVarP(&projectName, "project", "p", "", "Project name. Make sure the project is created on the website (required)")
-	c.Flags().StringToStringVarP(&stringMetadata, "metadatum", "m", map[string]string{}, "Metadatum name and value for your sample, ex. 'host=Human'")
-	c.Flags().StringVar(&metadataCSVPath, "metadata-csv", "", "Metadata local file path.")
-	c.Flags().BoolVar(&disableBuffer, "disable-buffer", false, "Disable shared buffer pool (useful if running out of memory)")
+	uploadFlags.Load(c, uploadFlags.Targets{
+		ProjectName:     &projectName,
+		StringMetadata:  &stringMetadata,
+		MetadataCSVPath: &metadataCSVPath,
+		CRAMReference:   &cramReference,
+		DisableBuffer:   &disableBuffer,
+		FlowOptions:     &flowOptions,
+	})
 }
 
 func validateCommonArgs() error {
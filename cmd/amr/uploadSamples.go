@@ -2,18 +2,21 @@ package amr
 
 import (
 	"errors"
-	"fmt"
 	"log"
 
 	"github.com/chanzuckerberg/czid-cli/pkg/czid"
 	"github.com/spf13/cobra"
 )
 
+var dryRun bool
+
 // uploadSamplesCmd represents the uploadSamples command
 var uploadSamplesCmd = &cobra.Command{
-	Use:   "upload-samples [directory]",
+	Use:   "upload-samples [directory|glob|file-list]...",
 	Short: "Bulk upload many samples",
-	Long:  "Bulk upload many samples",
+	Long: `Bulk upload many samples from one or more directories, glob patterns,
+or newline separated file lists. Samples with the same name in multiple
+inputs are merged and their lane files are concatenated.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		verbose, err := cmd.Flags().GetBool("verbose")
 		if err != nil {
@@ -25,15 +28,19 @@ var uploadSamplesCmd = &cobra.Command{
 		if len(args) == 0 {
 			return errors.New("missing required positional argument: directory")
 		}
-		if len(args) > 1 {
-			return fmt.Errorf("too many positional arguments, (maximum 1), args: %v", args)
-		}
-		directory := args[0]
-		sampleFiles, err := czid.SamplesFromDir(directory, verbose)
+		sampleFiles, err := czid.SamplesFromPaths(args, verbose, cramReference)
 		if err != nil {
 			log.Fatal(err)
 		}
 
+		// with --verbose the lane table was printed while discovering samples
+		if dryRun && verbose {
+			return nil
+		}
+		if dryRun {
+			return czid.PrintLaneTable(cmd.OutOrStdout(), sampleFiles)
+		}
+
 		options := czid.SampleOptions{}
 
 		return czid.UploadSamplesFlow(
@@ -44,6 +51,7 @@ var uploadSamplesCmd = &cobra.Command{
 			"amr",
 			options,
 			disableBuffer,
+			flowOptions,
 		)
 	},
 }
@@ -51,4 +59,5 @@ var uploadSamplesCmd = &cobra.Command{
 func init() {
 	AmrCmd.AddCommand(uploadSamplesCmd)
 	loadSharedFlags(uploadSamplesCmd)
+	uploadSamplesCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the files that will be uploaded for each sample without uploading")
 }
@@ -9,17 +9,21 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
+	"github.com/chanzuckerberg/czid-cli/cmd/uploadFlags"
+	"github.com/chanzuckerberg/czid-cli/pkg/czid"
 	"github.com/chanzuckerberg/czid-cli/pkg/util"
 )
 
 var projectName string
 var stringMetadata map[string]string
 var metadataCSVPath string
+var cramReference string
 var technology string
 var wetlabProtocol string
 var medakaModel string
 var clearLabs bool
 var disableBuffer bool
+var flowOptions czid.UploadFlowOptions
 
 var Technologies = map[string]string{
 	"Illumina": "Illumina",
@@ -116,9 +120,14 @@ func loadSharedFlags(c *cobra.Command) {
 		strings.Join(util.StringMapKeys(nanoporeWetLabProtocols), "\", \""),
 	)
 
-	c.Flags().StringVarP(&projectName, "project", "p", "", "Project name. Make sure the project is created on the website (required)")
-	c.Flags().StringToStringVarP(&stringMetadata, "metadatum", "m", map[string]string{}, "Metadatum name and value for your sample, ex. 'host=Human'")
-	c.Flags().StringVar(&metadataCSVPath, "metadata-csv", "", "Metadata local file path.")
+	uploadFlags.Load(c, uploadFlags.Targets{
+		ProjectName:     &projectName,
+		StringMetadata:  &stringMetadata,
+		MetadataCSVPath: &metadataCSVPath,
+		CRAMReference:   &cramReference,
+		DisableBuffer:   &disableBuffer,
+		FlowOptions:     &flowOptions,
+	})
 	c.Flags().StringVar(&technology, "sequencing-platform", "", fmt.Sprintf("Sequencing platform used to sequence the sample, options: %s", technologyOptionsString))
 	c.Flags().StringVar(&wetlabProtocol, "wetlab-protocol", "", fmt.Sprintf(
 		"Wetlab protocol followed. Only for SARS-CoV2, can't be used with reference-accession, reference-fasta, or primer-bed\n  Options for Nanopore (optional, default: \"%s\"): %s\n  Options for Illumina (required): %s",
@@ -139,7 +148,6 @@ func loadSharedFlags(c *cobra.Command) {
 	c.Flags().StringVar(&referenceAccession, "reference-accession", "", "Reference accession ID, used for general consensus genomes (not SARS-CoV2), cannot be used if reference-fasta is set, requires sequencing-platform 'Illumina'")
 	c.Flags().StringVar(&referenceFasta, "reference-fasta", "", "Local reference fasta file, used for general consensus genomes (not SARS-CoV2), requires sequencing-platform 'Illumina'")
 	c.Flags().StringVar(&primerBed, "primer-bed", "", "Local primer file (.bed), used for general consensus genomes (not SARS-CoV2), requires reference-fasta or reference-accession and sequencing-platform 'Illumina'")
-	c.Flags().BoolVar(&disableBuffer, "disable-buffer", false, "Disable shared buffer pool (useful if running out of memory)")
 }
 
 func validateCommonArgs() error {
@@ -41,13 +41,8 @@ var uploadSampleCmd = &cobra.Command{
 			primerBeds = []string{primerBed}
 		}
 
-		sampleFiles := map[string]czid.SampleFiles{
-			sampleName: {Single: []string{r1path}, ReferenceFasta: referenceFastas, PrimerBed: primerBeds},
-		}
-
 		if len(args) > 1 {
 			r2path = args[1]
-			sampleFiles[sampleName] = czid.SampleFiles{R1: []string{r1path}, R2: []string{r2path}, ReferenceFasta: referenceFastas, PrimerBed: primerBeds}
 		}
 		if len(args) > 2 {
 			return fmt.Errorf("too many positional arguments (maximum 2), args: %v", args)
@@ -56,6 +51,14 @@ var uploadSampleCmd = &cobra.Command{
 			return errors.New("r1 and r2 cannot be the same file")
 		}
 
+		files, err := czid.NewSampleFiles(sampleName, r1path, r2path, cramReference)
+		if err != nil {
+			return err
+		}
+		files.ReferenceFasta = referenceFastas
+		files.PrimerBed = primerBeds
+		sampleFiles := map[string]czid.SampleFiles{sampleName: files}
+
 		options := czid.SampleOptions{
 			Technology:         Technologies[technology],
 			WetlabProtocol:     WetlabProtocols[wetlabProtocol],
@@ -74,6 +77,7 @@ var uploadSampleCmd = &cobra.Command{
 			"consensus-genome",
 			options,
 			disableBuffer,
+			flowOptions,
 		)
 	},
 }
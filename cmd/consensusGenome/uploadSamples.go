@@ -2,18 +2,21 @@ package consensusGenome
 
 import (
 	"errors"
-	"fmt"
 	"log"
 
 	"github.com/chanzuckerberg/czid-cli/pkg/czid"
 	"github.com/spf13/cobra"
 )
 
+var dryRun bool
+
 // uploadSamplesCmd represents the uploadSamples command
 var uploadSamplesCmd = &cobra.Command{
-	Use:   "upload-samples [directory]",
+	Use:   "upload-samples [directory|glob|file-list]...",
 	Short: "Bulk upload many samples",
-	Long:  "Bulk upload many samples",
+	Long: `Bulk upload many samples from one or more directories, glob patterns,
+or newline separated file lists. Samples with the same name in multiple
+inputs are merged and their lane files are concatenated.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		verbose, err := cmd.Flags().GetBool("verbose")
 		if err != nil {
@@ -25,15 +28,19 @@ var uploadSamplesCmd = &cobra.Command{
 		if len(args) == 0 {
 			return errors.New("missing required positional argument: directory")
 		}
-		if len(args) > 1 {
-			return fmt.Errorf("too many positional arguments, (maximum 1), args: %v", args)
-		}
-		directory := args[0]
-		sampleFiles, err := czid.SamplesFromDir(directory, verbose)
+		sampleFiles, err := czid.SamplesFromPaths(args, verbose, cramReference)
 		if err != nil {
 			log.Fatal(err)
 		}
 
+		// with --verbose the lane table was printed while discovering samples
+		if dryRun && verbose {
+			return nil
+		}
+		if dryRun {
+			return czid.PrintLaneTable(cmd.OutOrStdout(), sampleFiles)
+		}
+
 		if referenceFasta != "" {
 			for sampleName, files := range sampleFiles {
 				files.ReferenceFasta = []string{referenceFasta}
@@ -66,6 +73,7 @@ var uploadSamplesCmd = &cobra.Command{
 			"consensus-genome",
 			options,
 			disableBuffer,
+			flowOptions,
 		)
 	},
 }
@@ -73,4 +81,5 @@ var uploadSamplesCmd = &cobra.Command{
 func init() {
 	ConsensusGenomeCmd.AddCommand(uploadSamplesCmd)
 	loadSharedFlags(uploadSamplesCmd)
+	uploadSamplesCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the files that will be uploaded for each sample without uploading")
 }
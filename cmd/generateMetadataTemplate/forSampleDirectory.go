@@ -9,7 +9,7 @@ import (
 )
 
 var forSampleDirectoryCmd = &cobra.Command{
-	Use:   "for-sample-directory [directory]",
+	Use:   "for-sample-directory [directory|glob|file-list]...",
 	Short: "Generate a metadata csv template for a directory of sample files",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		verbose, err := cmd.Flags().GetBool("verbose")
@@ -20,9 +20,8 @@ var forSampleDirectoryCmd = &cobra.Command{
 		if len(args) == 0 {
 			return errors.New("missing required positional argument: directory")
 		}
-		directory := args[0]
 
-		sampleFiles, err := czid.SamplesFromDir(directory, verbose)
+		sampleFiles, err := czid.SamplesFromPaths(args, verbose, "")
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -3,9 +3,12 @@ package generateMetadataTemplate
 import (
 	"encoding/csv"
 	"errors"
+	"fmt"
 	"io"
 	"log"
 	"os"
+	"sort"
+	"strings"
 
 	"github.com/chanzuckerberg/czid-cli/pkg/czid"
 	"github.com/spf13/cobra"
@@ -13,8 +16,67 @@ import (
 
 var stringMetadata map[string]string
 var output string
+var hostMapPath string
+var hostColumn string
+var prefillPath string
+var prefillSampleColumn string
+var prefillExtraColumns bool
+
+// readOptions are the options for reading --host-map and --prefill
+var readOptions czid.MetadataReadOptions
+
+// sampleHostGenomes returns the host organism of each sample for the
+// template and the host organisms known for each sample. Host organisms come
+// from the host map, then from the prefilled metadata, then from -m.
+func sampleHostGenomes(sampleNames []string, defaultHostGenome string, prefill czid.SamplesMetadata) ([]string, map[string]string) {
+	hostMap := map[string]string{}
+	if hostMapPath != "" {
+		var err error
+		hostMap, err = czid.ReadHostGenomeMap(hostMapPath, hostColumn, readOptions)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	prefillNames := make([]string, 0, len(prefill))
+	for name := range prefill {
+		prefillNames = append(prefillNames, name)
+	}
+	matches, _ := czid.MatchSampleNames(prefillNames, sampleNames)
+	for _, match := range matches {
+		if _, has := hostMap[match.Sample]; !has && prefill[match.Name].HostGenome != "" {
+			hostMap[match.Sample] = prefill[match.Name].HostGenome
+		}
+	}
+
+	hostGenomes := make([]string, len(sampleNames))
+	hasHostGenome := false
+	missing := []string{}
+	for i, sampleName := range sampleNames {
+		hostGenome, has := hostMap[sampleName]
+		if !has {
+			hostGenome = defaultHostGenome
+			if hostMapPath != "" {
+				missing = append(missing, sampleName)
+			}
+		}
+		hostGenomes[i] = hostGenome
+		hasHostGenome = hasHostGenome || hostGenome != ""
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		fmt.Fprintf(os.Stderr, "warning: no host organism in %s for samples: %s\n", hostMapPath, strings.Join(missing, ", "))
+	}
+	if !hasHostGenome {
+		return []string{}, hostMap
+	}
+	return hostGenomes, hostMap
+}
 
 func generateMetadataTemplate(cmd *cobra.Command, output string, sampleNames []string) {
+	if err := czid.CheckMetadataReadOptions(readOptions); err != nil {
+		log.Fatal(err)
+	}
+
 	var writer *csv.Writer
 	if output != "" {
 		f, err := os.Create(output)
@@ -27,8 +89,20 @@ func generateMetadataTemplate(cmd *cobra.Command, output string, sampleNames []s
 		writer = csv.NewWriter(cmd.OutOrStdout())
 	}
 
+	prefill := czid.SamplesMetadata{}
+	if prefillPath != "" {
+		var err error
+		prefillOptions := readOptions
+		prefillOptions.SampleNameColumn = prefillSampleColumn
+		prefill, err = czid.ReadMetadataFile(prefillPath, prefillOptions)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	metadata := czid.NewMetadata(stringMetadata)
-	templateCSV, err := czid.DefaultClient.GetTemplateCSV(sampleNames, metadata.HostGenome)
+	hostGenomes, hostMap := sampleHostGenomes(sampleNames, metadata.HostGenome, prefill)
+	templateCSV, err := czid.DefaultClient.GetTemplateCSVForHosts(sampleNames, hostGenomes)
 	templateCSV.LazyQuotes = true
 	if err != nil {
 		log.Fatal(err)
@@ -50,11 +124,7 @@ func generateMetadataTemplate(cmd *cobra.Command, output string, sampleNames []s
 		}
 	}
 
-	err = writer.Write(fieldNames)
-	if err != nil {
-		log.Fatal(err)
-	}
-
+	rows := [][]string{fieldNames}
 	fieldNameToIdx := make(map[string]int, len(fieldNames))
 	for idx, name := range fieldNames {
 		fieldNameToIdx[name] = idx
@@ -65,20 +135,51 @@ func generateMetadataTemplate(cmd *cobra.Command, output string, sampleNames []s
 			log.Fatal(err)
 		}
 		writeRow := make([]string, len(fieldNames))
+		sampleHostGenome := ""
+		if idx, has := fieldNameToIdx["Sample Name"]; has && idx < len(readRow) {
+			sampleHostGenome = hostMap[readRow[idx]]
+		}
 		for name, idx := range fieldNameToIdx {
 			if idx < len(readRow) {
 				writeRow[idx] = readRow[idx]
 			}
-			if val, has := stringMetadata[name]; has {
+			if name == "Host Organism" && sampleHostGenome != "" {
+				writeRow[idx] = sampleHostGenome
+			} else if val, has := stringMetadata[name]; has {
 				writeRow[idx] = val
 			}
 		}
-		err = writer.Write(writeRow)
-		if err != nil {
-			log.Fatal(err)
+		rows = append(rows, writeRow)
+	}
+
+	if prefillPath != "" {
+		var report czid.PrefillReport
+		rows, report = czid.PrefillTemplateRows(rows, prefill, prefillExtraColumns)
+		printPrefillReport(report)
+	}
+	err = writer.WriteAll(rows)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// printPrefillReport prints how the prefilled metadata was matched to the
+// template's samples to stderr so it doesn't mix with a template on stdout
+func printPrefillReport(report czid.PrefillReport) {
+	for _, match := range report.Matches {
+		if match.Reason != czid.SampleNameMatchedExactly {
+			fmt.Fprintf(os.Stderr, "%s\n", match)
 		}
 	}
-	writer.Flush()
+	if len(report.UnmatchedRows) > 0 {
+		fmt.Fprintf(os.Stderr, "warning: rows of %s that matched no sample: %s\n", prefillPath, strings.Join(report.UnmatchedRows, ", "))
+	}
+	if len(report.UnfilledSamples) > 0 {
+		fmt.Fprintf(os.Stderr, "warning: samples with no row in %s: %s\n", prefillPath, strings.Join(report.UnfilledSamples, ", "))
+	}
+	if len(report.DroppedColumns) > 0 {
+		fmt.Fprintf(os.Stderr, "warning: columns of %s that aren't in the template were dropped, keep them with --prefill-extra-columns: %s\n", prefillPath, strings.Join(report.DroppedColumns, ", "))
+	}
 }
 
 var GenerateMetadataTemplateCmd = &cobra.Command{
@@ -89,4 +190,12 @@ var GenerateMetadataTemplateCmd = &cobra.Command{
 func loadSharedFlags(c *cobra.Command) {
 	c.Flags().StringToStringVarP(&stringMetadata, "metadatum", "m", map[string]string{}, "Metadatum name and value for your sample, ex. 'host=Human'")
 	c.Flags().StringVarP(&output, "output", "o", "", "Output file path (optional, by default prints to stdout)")
+	c.Flags().StringVar(&hostMapPath, "host-map", "", "Metadata file or sample sheet with each sample's host organism, samples it doesn't include use the host organism from -m")
+	c.Flags().StringVar(&hostColumn, "host-column", "", "Column of --host-map with the host organism, defaults to the 'Host Organism' column")
+	c.Flags().StringVar(&prefillPath, "prefill", "", "Fill in the template with metadata from a previous metadata file, a 'czid metadata export', or a LIMS export")
+	c.Flags().StringVar(&prefillSampleColumn, "prefill-sample-column", "", "Column of --prefill with the sample names, defaults to the 'Sample Name' column")
+	c.Flags().StringVar(&readOptions.Sheet, "metadata-sheet", "", "Sheet name or number (starting at 1) to read from .xlsx --host-map and --prefill files, defaults to the first sheet")
+	c.Flags().StringVar(&readOptions.Encoding, "metadata-encoding", czid.MetadataEncodingAuto, fmt.Sprintf("Encoding of delimited --host-map and --prefill files, options: \"%s\"", strings.Join(czid.MetadataEncodings, "\", \"")))
+	c.Flags().StringVar(&readOptions.Delimiter, "metadata-delimiter", "auto", fmt.Sprintf("Delimiter of delimited --host-map and --prefill files, options: \"%s\"", strings.Join(czid.MetadataDelimiters, "\", \"")))
+	c.Flags().BoolVar(&prefillExtraColumns, "prefill-extra-columns", false, "Add columns of --prefill that aren't in the template after the template's columns instead of dropping them")
 }
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/chanzuckerberg/czid-cli/pkg/czid"
+	"github.com/spf13/cobra"
+)
+
+var hostOrganismsOffline bool
+
+var hostOrganismsCmd = &cobra.Command{
+	Use:   "host-organisms",
+	Short: "Commands related to host organisms",
+}
+
+var listHostOrganismsCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the host organisms supported by Chan Zuckerberg ID",
+	Long: `List the host organisms supported by Chan Zuckerberg ID. Host organisms
+marked with (ERCC only) have no host genome, only ERCC reads are filtered
+out of their samples. Samples with host organisms that aren't listed can
+still be uploaded but are also ERCC only.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		hostGenomes, err := czid.DefaultClient.GetHostGenomes(hostOrganismsOffline)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, h := range hostGenomes {
+			if h.ERCCOnly {
+				cmd.Printf("%s (ERCC only)\n", h.Name)
+			} else {
+				cmd.Println(h.Name)
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(hostOrganismsCmd)
+	hostOrganismsCmd.AddCommand(listHostOrganismsCmd)
+	listHostOrganismsCmd.Flags().BoolVar(&hostOrganismsOffline, "offline", false, "Only use cached host organisms")
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/chanzuckerberg/czid-cli/pkg/czid"
+	"github.com/spf13/cobra"
+)
+
+var inspectFormat string
+var inspectJobs int
+
+// formatQuality formats a mean quality, "-" if it is unknown
+func formatQuality(q *float64) string {
+	if q == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f", *q)
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func formatLaneNumbers(lanes []int) string {
+	if len(lanes) == 0 {
+		return "-"
+	}
+	s := make([]string, len(lanes))
+	for i, lane := range lanes {
+		s[i] = fmt.Sprint(lane)
+	}
+	return strings.Join(s, ",")
+}
+
+var inspectCmd = &cobra.Command{
+	Use:   "inspect [directory|glob|file-list]...",
+	Short: "Summarize local sample files before uploading",
+	Long: `Discover samples the same way upload-samples does and report,
+for each sample, its files, pairing, lanes, sizes, read count, read length
+distribution, mean base quality, and detected sequencing platform.
+Nothing is uploaded.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return errors.New("missing required positional argument: directory")
+		}
+		if inspectFormat != "table" && inspectFormat != "json" {
+			return fmt.Errorf("format \"%s\" not supported, please choose one of: \"table\", \"json\"", inspectFormat)
+		}
+
+		sampleFiles, err := czid.DiscoverSamples(args, false, "", cmd.ErrOrStderr())
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		stats, err := czid.InspectSamples(sampleFiles, inspectJobs)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if inspectFormat == "json" {
+			encoder := json.NewEncoder(cmd.OutOrStdout())
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(stats)
+		}
+
+		tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "SAMPLE\tFILES\tPAIRING\tLANES\tCOMPRESSED\tUNCOMPRESSED\tREADS\tLENGTH (MIN/MEDIAN/MEAN/MAX)\tMEAN Q\tPLATFORM")
+		for _, s := range stats {
+			fmt.Fprintf(
+				tw,
+				"%s\t%d\t%s\t%s\t%s\t%s\t%d\t%d/%d/%.1f/%d\t%s\t%s\n",
+				s.Name,
+				len(s.Files),
+				s.Pairing,
+				formatLaneNumbers(s.Lanes),
+				formatBytes(s.CompressedSize),
+				formatBytes(s.UncompressedSize),
+				s.Reads,
+				s.ReadLength.Min,
+				s.ReadLength.Median,
+				s.ReadLength.Mean,
+				s.ReadLength.Max,
+				formatQuality(s.MeanQuality),
+				s.Platform,
+			)
+		}
+		return tw.Flush()
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(inspectCmd)
+	inspectCmd.Flags().StringVar(&inspectFormat, "format", "table", "Output format, options: \"table\", \"json\"")
+	inspectCmd.Flags().IntVarP(&inspectJobs, "jobs", "j", runtime.NumCPU(), "Number of files to scan in parallel")
+}
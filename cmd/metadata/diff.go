@@ -0,0 +1,71 @@
+package metadata
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/chanzuckerberg/czid-cli/pkg/czid"
+	"github.com/spf13/cobra"
+)
+
+var diffFormat string
+var diffAllColumns bool
+
+var diffCmd = &cobra.Command{
+	Use:   "diff [metadata-file]",
+	Short: "Compare a metadata file with a project's metadata",
+	Long: `Compare each sample's metadata in a metadata file with its metadata in a
+project and print the values that would be added, changed, or removed.
+Column names, dates, and collection locations are resolved the same way as
+when uploading. Only the columns in the file are compared unless
+--all-columns is set. Samples in the file that aren't in the project are
+compared as if they had no metadata.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return errors.New("missing required positional argument: metadata-file")
+		}
+		if len(args) > 1 {
+			return fmt.Errorf("too many positional arguments (maximum 1), args: %v", args)
+		}
+		if diffFormat != "text" && diffFormat != "json" {
+			return fmt.Errorf("format \"%s\" not supported, please choose one of: \"text\", \"json\"", diffFormat)
+		}
+		if err := checkProjectFlags(); err != nil {
+			return err
+		}
+
+		// with JSON output only the JSON goes to stdout so it can be parsed
+		out := cmd.OutOrStdout()
+		if diffFormat == "json" {
+			out = cmd.ErrOrStderr()
+		}
+		_, projectSamples, samplesMetadata, issues := readProjectMetadata(args[0], true, bufio.NewReader(cmd.InOrStdin()), out)
+		if errorCount, _ := czid.CountIssues(issues); errorCount > 0 {
+			os.Exit(czid.ExitCodeValidationErrors)
+		}
+
+		changes := czid.DiffMetadata(projectSamples, samplesMetadata, diffAllColumns)
+		if diffFormat == "json" {
+			encoder := json.NewEncoder(cmd.OutOrStdout())
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(changes)
+		}
+		if len(changes) == 0 {
+			cmd.Println("no differences")
+			return nil
+		}
+		czid.PrintMetadataChanges(cmd.OutOrStdout(), changes)
+		return nil
+	},
+}
+
+func init() {
+	MetadataCmd.AddCommand(diffCmd)
+	loadSharedFlags(diffCmd)
+	loadProjectFlags(diffCmd)
+	diffCmd.Flags().StringVar(&diffFormat, "format", "text", "Output format, options: \"text\", \"json\"")
+	diffCmd.Flags().BoolVar(&diffAllColumns, "all-columns", false, "Also report values in CZ ID for columns the file doesn't have as removed")
+}
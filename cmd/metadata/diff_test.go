@@ -0,0 +1,85 @@
+package metadata
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chanzuckerberg/czid-cli/pkg/czid"
+)
+
+type mockAuth0Client struct{}
+
+func (c *mockAuth0Client) IDToken() (string, error) {
+	return "id", nil
+}
+
+func (c *mockAuth0Client) Login(headless bool, persistent bool) error {
+	return nil
+}
+
+func (c *mockAuth0Client) Secret() (string, bool) {
+	return "secret", true
+}
+
+// mockHTTPClient responds to each request with the response for its path
+type mockHTTPClient struct {
+	responses map[string]string
+}
+
+func (c *mockHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	body, has := c.responses[req.URL.Path]
+	if !has {
+		return &http.Response{StatusCode: 404, Body: io.NopCloser(strings.NewReader(""))}, nil
+	}
+	return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(body))}, nil
+}
+
+func TestDiffJSONOutput(t *testing.T) {
+	cacheHome := os.Getenv("XDG_CACHE_HOME")
+	defer os.Setenv("XDG_CACHE_HOME", cacheHome)
+	os.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	defaultClient := czid.DefaultClient
+	defer func() { czid.DefaultClient = defaultClient }()
+	czid.DefaultClient = czid.NewClient(&mockAuth0Client{}, &mockHTTPClient{responses: map[string]string{
+		"/projects.json": `{"projects": [{"name": "project", "id": 1}]}`,
+		"/samples/index_v2.json": `{"samples": [{"id": 2, "name": "sample_1", "details": {
+			"db_sample": {"host_genome_name": "Human"},
+			"metadata": {"sample_type": "Blood"}
+		}}]}`,
+		"/metadata/metadata_for_host_genome.json": `[{"key": "sample_type", "display_name": "Sample Type", "examples": "{}", "data_type": "string"}]`,
+	}})
+
+	path := filepath.Join(t.TempDir(), "metadata.csv")
+	// "Smaple Type" is resolved to "Sample Type" and the mapping is printed
+	contents := "Sample Name,Host Organism,Smaple Type\nsample_1,Human,Serum\n"
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := bytes.NewBufferString("")
+	stderr := bytes.NewBufferString("")
+	MetadataCmd.SetOut(stdout)
+	MetadataCmd.SetErr(stderr)
+	MetadataCmd.SetArgs([]string{"diff", path, "--project", "project", "--format", "json"})
+	if err := MetadataCmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	var changes []czid.MetadataChange
+	if err := json.Unmarshal(stdout.Bytes(), &changes); err != nil {
+		t.Fatalf("expected JSON on stdout, got %q: %s", stdout.String(), err)
+	}
+	if len(changes) != 1 || changes[0].Column != "Sample Type" || changes[0].New != "Serum" {
+		t.Errorf("expected a change to Sample Type, got %v", changes)
+	}
+	if !strings.Contains(stderr.String(), "Smaple Type") {
+		t.Errorf("expected the column mapping on stderr, got %q", stderr.String())
+	}
+}
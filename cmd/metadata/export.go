@@ -0,0 +1,82 @@
+package metadata
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/chanzuckerberg/czid-cli/pkg/czid"
+	"github.com/chanzuckerberg/czid-cli/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+var exportFormat string
+var exportOutput string
+var exportIncludeIDs bool
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Download the metadata of a project's samples",
+	Long: `Download the metadata of every sample in a project as CSV, TSV, XLSX,
+or JSON. The columns use the same names metadata files are read with, so
+an exported file can be edited and passed to 'czid metadata update'.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) > 0 {
+			return fmt.Errorf("too many positional arguments (maximum 0), args: %v", args)
+		}
+		if projectName == "" {
+			return errors.New("missing required argument: project")
+		}
+		format := exportFormat
+		if format == "" {
+			format = czid.MetadataFormatFromPath(exportOutput)
+		}
+		if format == "" {
+			format = "csv"
+		}
+		if !util.StringSliceContains(czid.MetadataFormats, format) {
+			return fmt.Errorf("format \"%s\" not supported, please choose one of: %s", format, strings.Join(czid.MetadataFormats, ", "))
+		}
+		if format == "xlsx" && exportOutput == "" {
+			return errors.New("xlsx export requires an output file, set one with --output")
+		}
+
+		projectID, err := czid.DefaultClient.GetProjectID(projectName)
+		if err != nil {
+			log.Fatal(err)
+		}
+		samples, err := czid.DefaultClient.GetProjectSamples(projectID)
+		if err != nil {
+			log.Fatal(err)
+		}
+		rows := czid.ProjectMetadataRows(samples, exportIncludeIDs)
+
+		var w io.Writer = cmd.OutOrStdout()
+		if exportOutput != "" {
+			f, err := os.Create(exportOutput)
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer f.Close()
+			w = f
+		}
+		if err := czid.WriteMetadataRows(w, format, rows); err != nil {
+			log.Fatal(err)
+		}
+		if exportOutput != "" {
+			cmd.PrintErrf("exported metadata of %d samples to %s\n", len(samples), exportOutput)
+		}
+		return nil
+	},
+}
+
+func init() {
+	MetadataCmd.AddCommand(exportCmd)
+	exportCmd.Flags().StringVarP(&projectName, "project", "p", "", "Project name")
+	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "File to write, defaults to stdout")
+	exportCmd.Flags().StringVar(&exportFormat, "format", "", fmt.Sprintf("Output format, options: \"%s\", defaults to the output file's extension or csv", strings.Join(czid.MetadataFormats, "\", \"")))
+	exportCmd.Flags().BoolVar(&exportIncludeIDs, "include-ids", false, "Include a 'Sample ID' column")
+}
@@ -0,0 +1,63 @@
+package metadata
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/chanzuckerberg/czid-cli/pkg/czid"
+	"github.com/spf13/cobra"
+)
+
+var lintCmd = &cobra.Command{
+	Use:   "lint [metadata-file] [directory|glob|file-list]...",
+	Short: "Check a metadata file for structural problems",
+	Long: `Check a metadata file for structural problems without contacting CZ ID:
+duplicate sample names, rows with missing or extra cells, column names that
+are variants of each other, and invisible characters that are trimmed when
+the file is read. If sample files are given, sample names that match none
+of them are reported too. This check also runs before uploading.
+
+Exits with code 1 if there are errors and code 3 if there are only warnings.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		verbose, err := cmd.Flags().GetBool("verbose")
+		if err != nil {
+			return err
+		}
+		if len(args) == 0 {
+			return errors.New("missing required positional argument: metadata-file")
+		}
+
+		var sampleNames []string
+		if len(args) > 1 {
+			sampleFiles, err := czid.SamplesFromPaths(args[1:], verbose, "")
+			if err != nil {
+				log.Fatal(err)
+			}
+			sampleNames = make([]string, 0, len(sampleFiles))
+			for sampleName := range sampleFiles {
+				sampleNames = append(sampleNames, sampleName)
+			}
+		}
+
+		issues, err := czid.LintMetadataFile(args[0], readOptions, sampleNames)
+		if err != nil {
+			log.Fatal(err)
+		}
+		czid.PrintMetadataIssues(os.Stdout, issues)
+		if exitCode := czid.ValidationExitCode(issues); exitCode != 0 {
+			os.Exit(exitCode)
+		}
+		cmd.Println("no problems found")
+		return nil
+	},
+}
+
+func init() {
+	MetadataCmd.AddCommand(lintCmd)
+	lintCmd.Flags().StringVar(&readOptions.Sheet, "metadata-sheet", "", "Sheet name or number (starting at 1) to read from an .xlsx metadata file, defaults to the first sheet")
+	lintCmd.Flags().StringVar(&readOptions.Encoding, "metadata-encoding", czid.MetadataEncodingAuto, fmt.Sprintf("Encoding of a delimited metadata file, options: \"%s\"", strings.Join(czid.MetadataEncodings, "\", \"")))
+	lintCmd.Flags().StringVar(&readOptions.Delimiter, "metadata-delimiter", "auto", fmt.Sprintf("Delimiter of a delimited metadata file, options: \"%s\"", strings.Join(czid.MetadataDelimiters, "\", \"")))
+}
@@ -0,0 +1,129 @@
+package metadata
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/chanzuckerberg/czid-cli/pkg/czid"
+	"github.com/chanzuckerberg/czid-cli/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+var stringMetadata map[string]string
+var offline bool
+var readOptions czid.MetadataReadOptions
+var headerResolverOptions czid.HeaderResolverOptions
+var dateOptions czid.DateOptions
+var projectName string
+var locationOptions czid.LocationOptions
+
+// MetadataCmd represents the metadata command
+var MetadataCmd = &cobra.Command{
+	Use:   "metadata",
+	Short: "Commands related to sample metadata",
+	Long:  "Commands related to sample metadata",
+}
+
+func loadSharedFlags(c *cobra.Command) {
+	c.Flags().StringToStringVarP(&stringMetadata, "metadatum", "m", map[string]string{}, "metadatum name and value for your samples, ex. 'host=Human'")
+	c.Flags().StringVar(&readOptions.Sheet, "metadata-sheet", "", "Sheet name or number (starting at 1) to read from an .xlsx metadata file, defaults to the first sheet")
+	c.Flags().StringVar(&readOptions.Encoding, "metadata-encoding", czid.MetadataEncodingAuto, fmt.Sprintf("Encoding of a delimited metadata file, options: \"%s\"", strings.Join(czid.MetadataEncodings, "\", \"")))
+	c.Flags().StringVar(&readOptions.Delimiter, "metadata-delimiter", "auto", fmt.Sprintf("Delimiter of a delimited metadata file, options: \"%s\"", strings.Join(czid.MetadataDelimiters, "\", \"")))
+	c.Flags().StringVar(&headerResolverOptions.AliasesPath, "metadata-aliases", "", "YAML or JSON file mapping your metadata column names to CZ ID metadata field names")
+	c.Flags().BoolVar(&headerResolverOptions.DisableFuzzy, "no-fuzzy-headers", false, "Don't match misspelled metadata column names to CZ ID metadata fields")
+	c.Flags().StringVar(&dateOptions.Order, "date-order", czid.DateOrderMonthFirst, fmt.Sprintf("Order of the day and month in numeric dates like 3/4/22, options: \"%s\"", strings.Join(czid.DateOrders, "\", \"")))
+}
+
+func loadProjectFlags(c *cobra.Command) {
+	c.Flags().StringVarP(&projectName, "project", "p", "", "Project name")
+	c.Flags().StringVar(&locationOptions.Mode, "location-mode", czid.LocationModeAuto, fmt.Sprintf("How to resolve collection locations with several matches, options: \"%s\"", strings.Join(czid.LocationModes, "\", \"")))
+	c.Flags().IntVar(&locationOptions.Suggestions, "location-suggestions", 5, "Number of matching locations to consider for each collection location in interactive and strict location modes")
+	c.Flags().StringVar(&locationOptions.ChoicesPath, "location-choices", "", "JSON file of chosen collection locations, used instead of searching and updated with choices made interactively")
+	c.Flags().StringVar(&locationOptions.LocationsPath, "locations-file", "", "YAML or JSON file mapping collection locations to fully specified locations, used instead of searching")
+	c.Flags().BoolVar(&locationOptions.NoCache, "no-location-cache", false, "Search for collection locations again instead of using locations cached by previous uploads")
+}
+
+// checkProjectFlags validates the flags added by loadProjectFlags
+func checkProjectFlags() error {
+	if projectName == "" {
+		return errors.New("missing required argument: project")
+	}
+	if !util.StringSliceContains(czid.LocationModes, locationOptions.Mode) {
+		return fmt.Errorf("location-mode \"%s\" not supported, please choose one of: %s", locationOptions.Mode, strings.Join(czid.LocationModes, ", "))
+	}
+	return nil
+}
+
+// readProjectMetadata reads a metadata file for samples already uploaded to
+// the project, matches it to the project's samples, and prepares it the same
+// way metadata is prepared for upload: column names are resolved, dates are
+// normalized, and collection locations are resolved. Local validation issues
+// are printed and returned. Samples that aren't in the project are fatal
+// unless allowUnmatched is true, then they are kept under their own names.
+// Everything but warnings is printed to out and answers to prompts are read
+// from in.
+func readProjectMetadata(metadataPath string, allowUnmatched bool, in *bufio.Reader, out io.Writer) (int, []czid.ProjectSample, czid.SamplesMetadata, []czid.MetadataIssue) {
+	if !util.StringSliceContains(czid.DateOrders, dateOptions.Order) {
+		log.Fatalf("date-order \"%s\" not supported, please choose one of: %s", dateOptions.Order, strings.Join(czid.DateOrders, ", "))
+	}
+
+	projectID, err := czid.DefaultClient.GetProjectID(projectName)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// uploaded samples can be matched by sample ID instead of name
+	projectReadOptions := readOptions
+	projectReadOptions.KeyBySampleID = true
+	samplesMetadata, err := czid.ReadMetadataFile(metadataPath, projectReadOptions)
+	if err != nil {
+		log.Fatal(err)
+	}
+	flagMetadata := czid.NewMetadata(stringMetadata)
+	for sampleName, m := range samplesMetadata {
+		samplesMetadata[sampleName] = m.Fuse(flagMetadata)
+	}
+
+	projectSamples, err := czid.DefaultClient.GetProjectSamples(projectID)
+	if err != nil {
+		log.Fatal(err)
+	}
+	matched, unmatched := czid.MatchProjectSamples(samplesMetadata, projectSamples)
+	if len(unmatched) > 0 {
+		if !allowUnmatched {
+			log.Fatalf("samples not found in project '%s': %s", projectName, strings.Join(unmatched, ", "))
+		}
+		fmt.Fprintf(os.Stderr, "warning: samples not found in project '%s': %s\n", projectName, strings.Join(unmatched, ", "))
+		for _, sampleName := range unmatched {
+			matched[sampleName] = samplesMetadata[sampleName]
+		}
+	}
+	samplesMetadata = matched
+
+	schemas, err := czid.DefaultClient.GetMetadataSchemas(samplesMetadata, false)
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = czid.ResolveHeaders(samplesMetadata, schemas, headerResolverOptions, out)
+	if err != nil {
+		log.Fatal(err)
+	}
+	czid.PrintDateChanges(out, czid.NormalizeDates(samplesMetadata, schemas, dateOptions))
+
+	issues := czid.ValidateMetadataUpdateLocally(samplesMetadata, schemas)
+	czid.PrintMetadataIssues(out, issues)
+	if errorCount, _ := czid.CountIssues(issues); errorCount > 0 {
+		return projectID, projectSamples, samplesMetadata, issues
+	}
+
+	err = czid.DefaultClient.ResolveCollectionLocations(samplesMetadata, locationOptions, in, out)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return projectID, projectSamples, samplesMetadata, issues
+}
@@ -0,0 +1,133 @@
+package metadata
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/chanzuckerberg/czid-cli/pkg/czid"
+	"github.com/spf13/cobra"
+)
+
+var updateYes bool
+var updateDryRun bool
+var updateValidationReportPath string
+
+var updateCmd = &cobra.Command{
+	Use:   "update [metadata-file]",
+	Short: "Update the metadata of samples already uploaded to a project",
+	Long: `Update the metadata of samples already uploaded to a project from a
+metadata file. Samples are matched by the 'Sample Name' column, or by a
+'Sample ID' column. Only the columns in the file are updated.
+
+The metadata is prepared and validated the same way as when uploading,
+including collection location resolution. The changes are shown and you
+are asked to confirm them before they are applied.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return errors.New("missing required positional argument: metadata-file")
+		}
+		if len(args) > 1 {
+			return fmt.Errorf("too many positional arguments (maximum 1), args: %v", args)
+		}
+		if err := checkProjectFlags(); err != nil {
+			return err
+		}
+		if updateValidationReportPath != "" {
+			if err := czid.CheckValidationReportPath(updateValidationReportPath); err != nil {
+				return err
+			}
+		}
+
+		// location choices and the confirmation read answers from the same
+		// reader so neither reads ahead into the other's answers
+		stdin := bufio.NewReader(cmd.InOrStdin())
+		projectID, projectSamples, samplesMetadata, issues := readProjectMetadata(args[0], false, stdin, os.Stdout)
+		if errorCount, _ := czid.CountIssues(issues); errorCount > 0 {
+			writeUpdateValidationReport(issues)
+			os.Exit(czid.ExitCodeValidationErrors)
+		}
+
+		changes := czid.DiffMetadata(projectSamples, samplesMetadata, false)
+		if len(changes) == 0 {
+			writeUpdateValidationReport(issues)
+			fmt.Println("no metadata changes")
+			return nil
+		}
+		changed := map[string]bool{}
+		for _, c := range changes {
+			changed[c.SampleName] = true
+		}
+		for sampleName := range samplesMetadata {
+			if !changed[sampleName] {
+				delete(samplesMetadata, sampleName)
+			}
+		}
+		// the metadata of human samples is checked for personal information
+		// the same way as when uploading so it can't be added by an update
+		if phiIssues := czid.CheckPHI(samplesMetadata, phiOptions); len(phiIssues) > 0 {
+			czid.PrintMetadataIssues(os.Stdout, phiIssues)
+			writeUpdateValidationReport(append(issues, phiIssues...))
+			os.Exit(czid.ExitCodeValidationErrors)
+		}
+		czid.PrintMetadataChanges(cmd.OutOrStdout(), changes)
+		fmt.Printf("\n%d changes to %d samples\n", len(changes), len(samplesMetadata))
+		if updateDryRun {
+			writeUpdateValidationReport(issues)
+			return nil
+		}
+
+		czid.FillMissingMetadata(samplesMetadata, projectSamples)
+		serverIssues, err := czid.DefaultClient.ValidateProjectMetadata(projectID, samplesMetadata)
+		issues = append(issues, serverIssues...)
+		writeUpdateValidationReport(issues)
+		if err != nil {
+			if errors.Is(err, czid.ErrMetadataValidationFailed) {
+				os.Exit(czid.ExitCodeValidationErrors)
+			}
+			log.Fatal(err)
+		}
+
+		if !updateYes {
+			fmt.Print("apply these changes? [y/N]: ")
+			input, err := stdin.ReadString('\n')
+			if err != nil && strings.TrimSpace(input) == "" {
+				return errors.New("metadata update cancelled")
+			}
+			if strings.ToLower(strings.TrimSpace(input)) != "y" {
+				fmt.Println("metadata update cancelled")
+				return nil
+			}
+		}
+
+		err = czid.DefaultClient.UploadProjectMetadata(projectID, samplesMetadata)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("updated metadata of %d samples\n", len(samplesMetadata))
+		return nil
+	},
+}
+
+func writeUpdateValidationReport(issues []czid.MetadataIssue) {
+	if updateValidationReportPath == "" {
+		return
+	}
+	if err := czid.WriteValidationReport(updateValidationReportPath, issues); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("wrote validation report to %s\n", updateValidationReportPath)
+}
+
+func init() {
+	MetadataCmd.AddCommand(updateCmd)
+	loadSharedFlags(updateCmd)
+	loadProjectFlags(updateCmd)
+	updateCmd.Flags().BoolVarP(&updateYes, "yes", "y", false, "Apply the changes without asking for confirmation")
+	updateCmd.Flags().BoolVar(&updateDryRun, "dry-run", false, "Show the changes without applying them")
+	updateCmd.Flags().StringVar(&updateValidationReportPath, "validation-report", "", "Write every metadata validation issue to a .json or .csv file")
+	updateCmd.Flags().StringArrayVar(&phiOptions.AllowColumns, "allow-phi-column", []string{}, "Metadata column of human samples confirmed to be de-identified, skipped when checking for personal information. Can be repeated")
+}
@@ -0,0 +1,94 @@
+package metadata
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/chanzuckerberg/czid-cli/pkg/czid"
+	"github.com/chanzuckerberg/czid-cli/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+var validationReportPath string
+var strictHosts bool
+var phiOptions czid.PHIOptions
+
+var validateCmd = &cobra.Command{
+	Use:   "validate [metadata-file]",
+	Short: "Validate a metadata file locally",
+	Long: `Validate a metadata file against the metadata fields of each sample's
+host organism without uploading anything. Host organisms CZ ID doesn't
+support are reported with the closest supported host organism, and values
+of human samples that look like personal information are errors. Metadata
+fields and host organisms are fetched from CZ ID and cached, with --offline
+only the cached ones are used.
+
+Exits with code 1 if there are errors and code 3 if there are only warnings.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return errors.New("missing required positional argument: metadata-file")
+		}
+		if len(args) > 1 {
+			return fmt.Errorf("too many positional arguments (maximum 1), args: %v", args)
+		}
+		if validationReportPath != "" {
+			if err := czid.CheckValidationReportPath(validationReportPath); err != nil {
+				return err
+			}
+		}
+		if !util.StringSliceContains(czid.DateOrders, dateOptions.Order) {
+			return fmt.Errorf("date-order \"%s\" not supported, please choose one of: %s", dateOptions.Order, strings.Join(czid.DateOrders, ", "))
+		}
+
+		samplesMetadata, err := czid.ReadMetadataFile(args[0], readOptions)
+		if err != nil {
+			log.Fatal(err)
+		}
+		flagMetadata := czid.NewMetadata(stringMetadata)
+		for sampleName, m := range samplesMetadata {
+			samplesMetadata[sampleName] = m.Fuse(flagMetadata)
+		}
+
+		schemas, err := czid.DefaultClient.GetMetadataSchemas(samplesMetadata, offline)
+		if err != nil {
+			log.Fatal(err)
+		}
+		err = czid.ResolveHeaders(samplesMetadata, schemas, headerResolverOptions, os.Stdout)
+		if err != nil {
+			log.Fatal(err)
+		}
+		czid.PrintDateChanges(os.Stdout, czid.NormalizeDates(samplesMetadata, schemas, dateOptions))
+
+		hostGenomes, err := czid.DefaultClient.GetHostGenomes(offline)
+		if err != nil {
+			log.Fatal(err)
+		}
+		issues := czid.CheckHostGenomes(samplesMetadata, hostGenomes, strictHosts)
+		issues = append(issues, czid.ValidateMetadataLocally(samplesMetadata, schemas)...)
+		issues = append(issues, czid.CheckPHI(samplesMetadata, phiOptions)...)
+		czid.PrintMetadataIssues(os.Stdout, issues)
+		if validationReportPath != "" {
+			if err := czid.WriteValidationReport(validationReportPath, issues); err != nil {
+				log.Fatal(err)
+			}
+			cmd.Printf("wrote validation report to %s\n", validationReportPath)
+		}
+		if exitCode := czid.ValidationExitCode(issues); exitCode != 0 {
+			os.Exit(exitCode)
+		}
+		cmd.Println("metadata is valid")
+		return nil
+	},
+}
+
+func init() {
+	MetadataCmd.AddCommand(validateCmd)
+	loadSharedFlags(validateCmd)
+	validateCmd.Flags().BoolVar(&offline, "offline", false, "Only use cached host organism metadata fields")
+	validateCmd.Flags().BoolVar(&strictHosts, "strict-hosts", false, "Report host organisms not supported by CZ ID as errors instead of warnings")
+	validateCmd.Flags().StringArrayVar(&phiOptions.AllowColumns, "allow-phi-column", []string{}, "Metadata column of human samples confirmed to be de-identified, skipped when checking for personal information. Can be repeated")
+	validateCmd.Flags().StringVar(&validationReportPath, "validation-report", "", "Write every metadata validation issue to a .json or .csv file")
+}
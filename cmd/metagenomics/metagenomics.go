@@ -6,6 +6,8 @@ import (
 	"os"
 	"strings"
 
+	"github.com/chanzuckerberg/czid-cli/cmd/uploadFlags"
+	"github.com/chanzuckerberg/czid-cli/pkg/czid"
 	"github.com/chanzuckerberg/czid-cli/pkg/util"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -14,7 +16,9 @@ import (
 var projectName string
 var stringMetadata map[string]string
 var metadataCSVPath string
+var cramReference string
 var disableBuffer bool
+var flowOptions czid.UploadFlowOptions
 var technology string
 var guppyBasecallerSetting string
 var workflow string
@@ -56,9 +60,14 @@ func loadSharedFlags(c *cobra.Command) {
 		strings.Join(util.StringMapKeys(GuppyBasecallerSettings), "\", \""),
 	)
 
-	c.Flags().StringVarP(&projectName, "project", "p", "", "Project name. Make sure the project is created on the website")
-	c.Flags().StringToStringVarP(&stringMetadata, "metadatum", "m", map[string]string{}, "metadatum name and value for your sample, ex. 'host=Human'")
-	c.Flags().StringVar(&metadataCSVPath, "metadata-csv", "", "Metadata local file path.")
+	uploadFlags.Load(c, uploadFlags.Targets{
+		ProjectName:     &projectName,
+		StringMetadata:  &stringMetadata,
+		MetadataCSVPath: &metadataCSVPath,
+		CRAMReference:   &cramReference,
+		DisableBuffer:   &disableBuffer,
+		FlowOptions:     &flowOptions,
+	})
 	c.Flags().StringVar(&technology, "sequencing-platform", "", fmt.Sprintf("Sequencing platform used to sequence the sample, options: %s", technologyOptionsString))
 	c.Flags().StringVar(
 		&guppyBasecallerSetting,
@@ -67,7 +76,6 @@ func loadSharedFlags(c *cobra.Command) {
 		fmt.Sprintf("Specifies which basecalling model of 'Guppy' was used to generate the data. Required for Nanopore, not supported for Illumina. options: %s",
 			guppBasecallerSettingOptionsString),
 	)
-	c.Flags().BoolVar(&disableBuffer, "disable-buffer", false, "Disable shared buffer pool (useful if running out of memory)")
 }
 
 func validateCommonArgs() error {
@@ -31,13 +31,8 @@ var uploadSampleCmd = &cobra.Command{
 			sampleName = czid.ToSampleName(r1path)
 		}
 
-		sampleFiles := map[string]czid.SampleFiles{
-			sampleName: {Single: []string{r1path}},
-		}
-
 		if len(args) > 1 {
 			r2path = args[1]
-			sampleFiles[sampleName] = czid.SampleFiles{R1: []string{r1path}, R2: []string{r2path}}
 		}
 		if len(args) > 2 {
 			return fmt.Errorf("too many positional arguments (maximum 2), args: %v", args)
@@ -46,6 +41,12 @@ var uploadSampleCmd = &cobra.Command{
 			return errors.New("r1 and r2 cannot be the same file")
 		}
 
+		files, err := czid.NewSampleFiles(sampleName, r1path, r2path, cramReference)
+		if err != nil {
+			return err
+		}
+		sampleFiles := map[string]czid.SampleFiles{sampleName: files}
+
 		return czid.UploadSamplesFlow(
 			sampleFiles,
 			stringMetadata,
@@ -56,6 +57,7 @@ var uploadSampleCmd = &cobra.Command{
 				Technology: Technologies[technology],
 			},
 			disableBuffer,
+			flowOptions,
 		)
 	},
 }
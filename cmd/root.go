@@ -10,6 +10,7 @@ import (
 	"github.com/chanzuckerberg/czid-cli/cmd/amr"
 	"github.com/chanzuckerberg/czid-cli/cmd/consensusGenome"
 	"github.com/chanzuckerberg/czid-cli/cmd/generateMetadataTemplate"
+	"github.com/chanzuckerberg/czid-cli/cmd/metadata"
 	"github.com/chanzuckerberg/czid-cli/cmd/metagenomics"
 	"github.com/chanzuckerberg/czid-cli/pkg/util"
 	"github.com/spf13/cobra"
@@ -29,6 +30,7 @@ func init() {
 	RootCmd.AddCommand(consensusGenome.ConsensusGenomeCmd)
 	RootCmd.AddCommand(amr.AmrCmd)
 	RootCmd.AddCommand(generateMetadataTemplate.GenerateMetadataTemplateCmd)
+	RootCmd.AddCommand(metadata.MetadataCmd)
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
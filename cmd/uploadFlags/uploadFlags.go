@@ -0,0 +1,60 @@
+package uploadFlags
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/chanzuckerberg/czid-cli/pkg/czid"
+	"github.com/chanzuckerberg/czid-cli/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+// Targets are the variables an upload command reads its shared flags into
+type Targets struct {
+	ProjectName     *string
+	StringMetadata  *map[string]string
+	MetadataCSVPath *string
+	CRAMReference   *string
+	DisableBuffer   *bool
+	FlowOptions     *czid.UploadFlowOptions
+}
+
+// Load adds the flags shared by the upload commands of every workflow to c,
+// workflow specific flags are added by each workflow
+func Load(c *cobra.Command, t Targets) {
+	o := t.FlowOptions
+	c.Flags().StringVarP(t.ProjectName, "project", "p", "", "Project name. Make sure the project is created on the website (required)")
+	c.Flags().StringToStringVarP(t.StringMetadata, "metadatum", "m", map[string]string{}, "Metadatum name and value for your sample, ex. 'host=Human'")
+	c.Flags().SetNormalizeFunc(util.FlagAliases(map[string]string{"metadata-file": "metadata-csv"}))
+	c.Flags().StringVar(t.MetadataCSVPath, "metadata-csv", "", "Metadata local file path (.csv, .tsv, .xlsx, .json, or .yaml), --metadata-file is an alias.")
+	c.Flags().StringVar(t.CRAMReference, "cram-reference", "", "Reference FASTA the CRAM files were compressed against, CRAM files are converted with samtools and skipped without it")
+	c.Flags().StringVar(&o.MetadataReadOptions.Sheet, "metadata-sheet", "", "Sheet name or number (starting at 1) to read from an .xlsx metadata file, defaults to the first sheet")
+	c.Flags().StringVar(&o.MetadataReadOptions.Encoding, "metadata-encoding", czid.MetadataEncodingAuto, fmt.Sprintf("Encoding of a delimited metadata file, options: \"%s\"", strings.Join(czid.MetadataEncodings, "\", \"")))
+	c.Flags().StringVar(&o.MetadataReadOptions.Delimiter, "metadata-delimiter", "auto", fmt.Sprintf("Delimiter of a delimited metadata file, options: \"%s\"", strings.Join(czid.MetadataDelimiters, "\", \"")))
+	c.Flags().StringVar(&o.HeaderResolverOptions.AliasesPath, "metadata-aliases", "", "YAML or JSON file mapping your metadata column names to CZ ID metadata field names")
+	c.Flags().BoolVar(&o.HeaderResolverOptions.DisableFuzzy, "no-fuzzy-headers", false, "Don't match misspelled metadata column names to CZ ID metadata fields")
+	c.Flags().StringVar(&o.LocationOptions.Mode, "location-mode", czid.LocationModeAuto, fmt.Sprintf("How to resolve collection locations with several matches, options: \"%s\"", strings.Join(czid.LocationModes, "\", \"")))
+	c.Flags().IntVar(&o.LocationOptions.Suggestions, "location-suggestions", 5, "Number of matching locations to consider for each collection location in interactive and strict location modes")
+	c.Flags().StringVar(&o.LocationOptions.ChoicesPath, "location-choices", "", "JSON file of chosen collection locations, used instead of searching and updated with choices made interactively")
+	c.Flags().StringVar(&o.LocationOptions.LocationsPath, "locations-file", "", "YAML or JSON file mapping collection locations to fully specified locations, used instead of searching")
+	c.Flags().BoolVar(&o.LocationOptions.NoCache, "no-location-cache", false, "Search for collection locations again instead of using locations cached by previous uploads")
+	c.Flags().StringVar(&o.DateOptions.Order, "date-order", czid.DateOrderMonthFirst, fmt.Sprintf("Order of the day and month in numeric dates like 3/4/22, options: \"%s\"", strings.Join(czid.DateOrders, "\", \"")))
+	c.Flags().StringArrayVar(&o.MetadataReadOptions.Rules.PathPatterns, "path-pattern", []string{}, "Regular expression matched against input file paths, named groups like (?P<collection_date>\\d{8}) fill in empty metadata columns. Can be repeated")
+	c.Flags().StringToStringVar(&o.MetadataReadOptions.Rules.Defaults, "metadata-default", map[string]string{}, "Default value for empty metadata columns, ex. 'Sample Type=Nasopharyngeal Swab'")
+	c.Flags().BoolVar(t.DisableBuffer, "disable-buffer", false, "Disable shared buffer pool (useful if running out of memory)")
+	c.Flags().BoolVar(&o.AllowDuplicateContent, "allow-duplicate-content", false, "Upload even if input files have identical content")
+	c.Flags().BoolVar(&o.FullHash, "full-hash", false, "Hash entire input files when checking for duplicate content instead of sampled blocks")
+	c.Flags().StringVar(
+		&o.NameConflictPolicy,
+		"on-name-conflict",
+		czid.NameConflictRename,
+		fmt.Sprintf("What to do with samples whose names already exist in the project, options: %s", strings.Join(czid.NameConflictPolicies, ", ")),
+	)
+	c.Flags().StringVar(&o.NameMappingPath, "name-mapping", "", "Write a CSV mapping original sample names to final sample names and sample IDs to this path")
+	c.Flags().BoolVar(&o.SampleMatchOptions.AcceptMatches, "accept-matches", false, "Use the metadata of rows whose sample names are close to a sample's name, like S-01 for S01, without asking")
+	c.Flags().StringVar(&o.SampleMatchOptions.MappingPath, "match-mapping", "", "Write a CSV mapping metadata sample names to the sample names they were matched with to this path")
+	c.Flags().BoolVar(&o.SkipLocalValidation, "skip-local-validation", false, "Skip checking metadata against cached host organism metadata fields before uploading")
+	c.Flags().StringVar(&o.ValidationReportPath, "validation-report", "", "Write every metadata validation issue to a .json or .csv file")
+	c.Flags().BoolVar(&o.StrictHosts, "strict-hosts", false, "Refuse to upload samples whose host organism is not supported by CZ ID")
+	c.Flags().StringArrayVar(&o.PHIOptions.AllowColumns, "allow-phi-column", []string{}, "Metadata column of human samples confirmed to be de-identified, skipped when checking for personal information. Can be repeated")
+}
@@ -10,8 +10,10 @@ require (
 	github.com/aws/smithy-go v1.2.0
 	github.com/cheggaaa/pb/v3 v3.0.6
 	github.com/spf13/cobra v1.1.3
+	github.com/spf13/pflag v1.0.5
 	github.com/spf13/viper v1.7.1
 	golang.org/x/sys v0.21.0 // indirect
+	gopkg.in/yaml.v2 v2.4.0
 )
 
 replace github.com/aws/aws-sdk-go-v2/feature/s3/manager => github.com/chanzuckerberg/aws-sdk-go-v2/feature/s3/manager v1.1.0
@@ -0,0 +1,202 @@
+package bam
+
+// This file is for reading unaligned and aligned BAM files without samtools
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// SAM flags, see https://samtools.github.io/hts-specs/SAMv1.pdf
+const (
+	FlagPaired        uint16 = 0x1
+	FlagReverse       uint16 = 0x10
+	FlagRead1         uint16 = 0x40
+	FlagRead2         uint16 = 0x80
+	FlagSecondary     uint16 = 0x100
+	FlagSupplementary uint16 = 0x800
+)
+
+var bamMagic = []byte("BAM\x01")
+
+// seqNibbles maps the 4 bit encoded bases of a BAM record to letters
+const seqNibbles = "=ACMGRSVTWYHKDBN"
+
+// missingQuality is the phred+33 character used when a record has no qualities
+const missingQuality = 'I'
+
+// Record is the subset of a BAM alignment record needed to produce FASTQ
+type Record struct {
+	Name string
+	Flag uint16
+	Seq  []byte
+	Qual []byte
+	// MissingQual is true if the record has no qualities, Qual is then
+	// filled with missingQuality so the record can still be written as FASTQ
+	MissingQual bool
+}
+
+// IsPrimary is false for secondary and supplementary alignments, which
+// duplicate reads already present in the file
+func (r Record) IsPrimary() bool {
+	return r.Flag&(FlagSecondary|FlagSupplementary) == 0
+}
+
+// SortOrderCoordinate is the sort order of BAM files sorted by alignment
+// position, the mates of paired reads are not next to each other in them
+const SortOrderCoordinate = "coordinate"
+
+// Reader reads records from a BAM file. BGZF is a series of concatenated gzip
+// members so the standard library's multistream gzip reader decompresses it.
+type Reader struct {
+	r *bufio.Reader
+	// SortOrder is the SO tag of the header's @HD line, like "unsorted",
+	// "queryname", or "coordinate", or "" if the header doesn't have one
+	SortOrder string
+}
+
+// headerSortOrder returns the SO tag of the @HD line of a SAM header
+func headerSortOrder(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		if !strings.HasPrefix(line, "@HD\t") {
+			continue
+		}
+		for _, field := range strings.Split(strings.TrimRight(line, "\r\x00"), "\t") {
+			if strings.HasPrefix(field, "SO:") {
+				return strings.TrimPrefix(field, "SO:")
+			}
+		}
+	}
+	return ""
+}
+
+// NewReader reads the BAM header from r and returns a Reader positioned at
+// the first record
+func NewReader(r io.Reader) (*Reader, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a BGZF compressed file: %w", err)
+	}
+	br := bufio.NewReader(gz)
+
+	magic := make([]byte, len(bamMagic))
+	if _, err := io.ReadFull(br, magic); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(magic, bamMagic) {
+		return nil, errors.New("not a BAM file")
+	}
+
+	var lText int32
+	if err := binary.Read(br, binary.LittleEndian, &lText); err != nil {
+		return nil, err
+	}
+	text := make([]byte, lText)
+	if _, err := io.ReadFull(br, text); err != nil {
+		return nil, err
+	}
+
+	var nRef int32
+	if err := binary.Read(br, binary.LittleEndian, &nRef); err != nil {
+		return nil, err
+	}
+	for i := int32(0); i < nRef; i++ {
+		var lName int32
+		if err := binary.Read(br, binary.LittleEndian, &lName); err != nil {
+			return nil, err
+		}
+		// name followed by the int32 reference length
+		if _, err := br.Discard(int(lName) + 4); err != nil {
+			return nil, err
+		}
+	}
+	return &Reader{r: br, SortOrder: headerSortOrder(string(text))}, nil
+}
+
+// Read returns the next record, or io.EOF when there are no more records
+func (r *Reader) Read() (Record, error) {
+	var blockSize int32
+	if err := binary.Read(r.r, binary.LittleEndian, &blockSize); err != nil {
+		return Record{}, err
+	}
+	if blockSize < 32 {
+		return Record{}, fmt.Errorf("invalid BAM record size %d", blockSize)
+	}
+	block := make([]byte, blockSize)
+	if _, err := io.ReadFull(r.r, block); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return Record{}, err
+	}
+
+	lReadName := int(block[8])
+	nCigarOp := int(binary.LittleEndian.Uint16(block[12:14]))
+	flag := binary.LittleEndian.Uint16(block[14:16])
+	lSeq := int(binary.LittleEndian.Uint32(block[16:20]))
+
+	offset := 32
+	end := offset + lReadName + 4*nCigarOp + (lSeq+1)/2 + lSeq
+	if end > len(block) {
+		return Record{}, errors.New("truncated BAM record")
+	}
+	name := block[offset : offset+lReadName]
+	offset += lReadName + 4*nCigarOp
+	packedSeq := block[offset : offset+(lSeq+1)/2]
+	offset += (lSeq + 1) / 2
+	qual := block[offset : offset+lSeq]
+
+	record := Record{
+		Name:        string(bytes.TrimRight(name, "\x00")),
+		Flag:        flag,
+		Seq:         make([]byte, lSeq),
+		Qual:        make([]byte, lSeq),
+		MissingQual: lSeq > 0 && qual[0] == 0xff,
+	}
+	for i := 0; i < lSeq; i++ {
+		b := packedSeq[i/2]
+		if i%2 == 0 {
+			b >>= 4
+		}
+		record.Seq[i] = seqNibbles[b&0xf]
+		if record.MissingQual {
+			record.Qual[i] = missingQuality
+		} else {
+			record.Qual[i] = qual[i] + 33
+		}
+	}
+
+	// aligned reads on the reverse strand are stored reverse complemented
+	if flag&FlagReverse != 0 {
+		reverseComplement(record.Seq)
+		reverse(record.Qual)
+	}
+	return record, nil
+}
+
+var complements = map[byte]byte{
+	'A': 'T', 'T': 'A', 'C': 'G', 'G': 'C',
+	'M': 'K', 'K': 'M', 'R': 'Y', 'Y': 'R',
+	'V': 'B', 'B': 'V', 'H': 'D', 'D': 'H',
+}
+
+func reverse(b []byte) {
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+}
+
+func reverseComplement(seq []byte) {
+	reverse(seq)
+	for i, b := range seq {
+		if c, has := complements[b]; has {
+			seq[i] = c
+		}
+	}
+}
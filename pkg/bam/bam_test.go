@@ -0,0 +1,258 @@
+package bam
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type testRecord struct {
+	name string
+	flag uint16
+	seq  string
+	qual []byte
+}
+
+var seqCodes = map[byte]byte{'=': 0, 'A': 1, 'C': 2, 'G': 4, 'T': 8, 'N': 15}
+
+func encodeRecord(r testRecord) []byte {
+	var block bytes.Buffer
+	fixed := make([]byte, 32)
+	binary.LittleEndian.PutUint32(fixed[0:4], 0xffffffff) // refID -1
+	binary.LittleEndian.PutUint32(fixed[4:8], 0xffffffff) // pos -1
+	fixed[8] = byte(len(r.name) + 1)
+	binary.LittleEndian.PutUint16(fixed[14:16], r.flag)
+	binary.LittleEndian.PutUint32(fixed[16:20], uint32(len(r.seq)))
+	binary.LittleEndian.PutUint32(fixed[20:24], 0xffffffff)
+	binary.LittleEndian.PutUint32(fixed[24:28], 0xffffffff)
+	block.Write(fixed)
+	block.WriteString(r.name)
+	block.WriteByte(0)
+	packed := make([]byte, (len(r.seq)+1)/2)
+	for i := 0; i < len(r.seq); i++ {
+		code := seqCodes[r.seq[i]]
+		if i%2 == 0 {
+			packed[i/2] |= code << 4
+		} else {
+			packed[i/2] |= code
+		}
+	}
+	block.Write(packed)
+	block.Write(r.qual)
+
+	var out bytes.Buffer
+	binary.Write(&out, binary.LittleEndian, int32(block.Len()))
+	out.Write(block.Bytes())
+	return out.Bytes()
+}
+
+func writeTestBAM(t *testing.T, records []testRecord) string {
+	return writeTestBAMWithHeader(t, "@HD\tVN:1.6\n", records)
+}
+
+func writeTestBAMWithHeader(t *testing.T, header string, records []testRecord) string {
+	f, err := os.CreateTemp("", "*.bam")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	gz.Write(bamMagic)
+	binary.Write(gz, binary.LittleEndian, int32(len(header)))
+	gz.Write([]byte(header))
+	binary.Write(gz, binary.LittleEndian, int32(0))
+	for _, r := range records {
+		gz.Write(encodeRecord(r))
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return f.Name()
+}
+
+var pairedRecords = []testRecord{
+	{"read1", FlagPaired | FlagRead1, "ACGT", []byte{30, 30, 30, 30}},
+	{"read1", FlagPaired | FlagRead2, "GGA", []byte{20, 20, 20}},
+	{"read1", FlagPaired | FlagRead1 | FlagSecondary, "ACGT", []byte{30, 30, 30, 30}},
+	{"read2", FlagPaired | FlagRead1 | FlagReverse, "AACG", []byte{10, 20, 30, 40}},
+	{"read2", FlagPaired | FlagRead2, "TTN", []byte{0xff, 0xff, 0xff}},
+}
+
+func TestRead(t *testing.T) {
+	path := writeTestBAM(t, pairedRecords)
+	defer os.Remove(path)
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	reader, err := NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records := []Record{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, record)
+	}
+
+	if len(records) != len(pairedRecords) {
+		t.Fatalf("expected %d records but got %d", len(pairedRecords), len(records))
+	}
+
+	if records[0].Name != "read1" || string(records[0].Seq) != "ACGT" || string(records[0].Qual) != "????" {
+		t.Errorf("unexpected first record: %s %s %s", records[0].Name, records[0].Seq, records[0].Qual)
+	}
+
+	if string(records[3].Seq) != "CGTT" || string(records[3].Qual) != "I?5+" {
+		t.Errorf("expected reverse strand read to be reverse complemented but got %s %s", records[3].Seq, records[3].Qual)
+	}
+
+	if string(records[4].Qual) != "III" {
+		t.Errorf("expected missing qualities to be filled but got %s", records[4].Qual)
+	}
+
+	if records[0].MissingQual || !records[4].MissingQual {
+		t.Errorf("expected only the record without qualities to be marked as missing them")
+	}
+}
+
+func TestIsPaired(t *testing.T) {
+	path := writeTestBAM(t, pairedRecords)
+	defer os.Remove(path)
+
+	paired, err := IsPaired(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !paired {
+		t.Error("expected BAM to be paired")
+	}
+
+	sorted := writeTestBAMWithHeader(t, "@HD\tVN:1.6\tSO:coordinate\n", pairedRecords)
+	defer os.Remove(sorted)
+	_, err = IsPaired(sorted, "")
+	if err == nil || !strings.Contains(err.Error(), "samtools collate") {
+		t.Errorf("expected an error for paired reads sorted by coordinate but got %v", err)
+	}
+
+	unpaired := writeTestBAMWithHeader(t, "@HD\tVN:1.6\tSO:coordinate\n", []testRecord{{"read1", 0, "ACGT", []byte{30, 30, 30, 30}}})
+	defer os.Remove(unpaired)
+	if _, err := IsPaired(unpaired, ""); err != nil {
+		t.Errorf("expected single end reads sorted by coordinate to be supported but got %v", err)
+	}
+}
+
+func TestHeaderSortOrder(t *testing.T) {
+	header := "@HD\tVN:1.6\tSO:queryname\n@SQ\tSN:chr1\tLN:100\n"
+	if sortOrder := headerSortOrder(header); sortOrder != "queryname" {
+		t.Errorf("expected queryname but got %s", sortOrder)
+	}
+	if sortOrder := headerSortOrder("@SQ\tSN:chr1\tLN:100\n"); sortOrder != "" {
+		t.Errorf("expected no sort order but got %s", sortOrder)
+	}
+}
+
+func TestGzippedFASTQ(t *testing.T) {
+	path := writeTestBAM(t, pairedRecords)
+	defer os.Remove(path)
+
+	reader := GzippedFASTQ([]string{path}, "", Mate2)
+	defer reader.Close()
+	gz, err := gzip.NewReader(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "@read1/2\nGGA\n+\n555\n@read2/2\nTTN\n+\nIII\n"
+	if string(b) != expected {
+		t.Errorf("expected:\n%s\nbut got:\n%s", expected, string(b))
+	}
+}
+
+func TestGzippedFASTQOrphan(t *testing.T) {
+	records := []testRecord{
+		{"read1", FlagPaired | FlagRead1, "ACGT", []byte{30, 30, 30, 30}},
+		{"read2", FlagPaired | FlagRead1, "AACG", []byte{10, 20, 30, 40}},
+		{"read2", FlagPaired | FlagRead2, "TTN", []byte{20, 20, 20}},
+	}
+	path := writeTestBAM(t, records)
+	defer os.Remove(path)
+
+	for _, mate := range []Mate{Mate1, Mate2} {
+		reader := GzippedFASTQ([]string{path}, "", mate)
+		_, err := io.ReadAll(reader)
+		reader.Close()
+		if err == nil || !strings.Contains(err.Error(), "read read1") {
+			t.Errorf("expected an error for the orphan read1 but got %v", err)
+		}
+	}
+}
+
+func TestGzippedFASTQCRAM(t *testing.T) {
+	dir := t.TempDir()
+	// the fake samtools prints the file it is given, which is a BAM file
+	// named like a CRAM file
+	fakeSamtools := filepath.Join(dir, "samtools")
+	if err := os.WriteFile(fakeSamtools, []byte("#!/bin/sh\nshift $(($# - 1))\ncat \"$1\"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	defer func(s string) { samtools = s }(samtools)
+	samtools = fakeSamtools
+
+	bamPath := writeTestBAM(t, pairedRecords)
+	defer os.Remove(bamPath)
+	cramPath := filepath.Join(dir, "sample.cram")
+	if err := os.Rename(bamPath, cramPath); err != nil {
+		t.Fatal(err)
+	}
+	reference := filepath.Join(dir, "reference.fa")
+	if err := os.WriteFile(reference, []byte(">chr1\nACGT\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := IsPaired(cramPath, ""); err == nil {
+		t.Error("expected an error for a CRAM file without a reference")
+	}
+	paired, err := IsPaired(cramPath, reference)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !paired {
+		t.Error("expected the CRAM file to be paired")
+	}
+
+	reader := GzippedFASTQ([]string{cramPath}, reference, Mate2)
+	defer reader.Close()
+	gz, err := gzip.NewReader(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "@read1/2\nGGA\n+\n555\n@read2/2\nTTN\n+\nIII\n"
+	if string(b) != expected {
+		t.Errorf("expected:\n%s\nbut got:\n%s", expected, string(b))
+	}
+}
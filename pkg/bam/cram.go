@@ -0,0 +1,74 @@
+package bam
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+var cramExp = regexp.MustCompile(`\.cram$`)
+
+// IsCRAM reports whether path is a CRAM file. Decoding CRAM needs the
+// reference the reads were compressed against, CRAM files are converted to
+// BAM with samtools.
+func IsCRAM(path string) bool {
+	return cramExp.MatchString(path)
+}
+
+// samtools is the command CRAM files are converted with, it is a variable so
+// tests can replace it
+var samtools = "samtools"
+
+// cramReader streams the BAM output of samtools, closing it waits for
+// samtools to exit
+type cramReader struct {
+	io.ReadCloser
+	cmd    *exec.Cmd
+	path   string
+	stderr *bytes.Buffer
+}
+
+func (r *cramReader) Close() error {
+	r.ReadCloser.Close()
+	if err := r.cmd.Wait(); err != nil {
+		return fmt.Errorf("converting CRAM file %s with samtools: %w: %s", r.path, err, strings.TrimSpace(r.stderr.String()))
+	}
+	return nil
+}
+
+// Open opens a BAM file for NewReader. A CRAM file is converted to BAM by
+// samtools as it is read, reference is the FASTA file it was compressed
+// against and is required for CRAM files.
+func Open(path string, reference string) (io.ReadCloser, error) {
+	if !IsCRAM(path) {
+		return os.Open(path)
+	}
+	if reference == "" {
+		return nil, fmt.Errorf("CRAM file %s needs the reference it was compressed against, pass it with --cram-reference", path)
+	}
+	if _, err := os.Stat(reference); err != nil {
+		return nil, fmt.Errorf("reading reference for CRAM file %s: %w", path, err)
+	}
+	if _, err := exec.LookPath(samtools); err != nil {
+		return nil, errors.New("converting CRAM files needs samtools, install it or convert " + path + " to BAM or FASTQ first")
+	}
+
+	// -u writes uncompressed BAM, it is only read once so compressing it
+	// would be wasted work
+	cmd := exec.Command(samtools, "view", "-u", "-T", reference, path)
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &cramReader{ReadCloser: stdout, cmd: cmd, path: path, stderr: stderr}, nil
+}
@@ -0,0 +1,206 @@
+package bam
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Mate selects which reads of a BAM file are written as FASTQ
+type Mate int
+
+const (
+	// MateSingle selects every primary read, for single end data
+	MateSingle Mate = iota
+	// Mate1 selects primary reads flagged READ1
+	Mate1
+	// Mate2 selects primary reads flagged READ2
+	Mate2
+)
+
+func (m Mate) matches(r Record) bool {
+	if !r.IsPrimary() {
+		return false
+	}
+	switch m {
+	case Mate1:
+		return r.Flag&FlagRead1 != 0
+	case Mate2:
+		return r.Flag&FlagRead2 != 0
+	default:
+		return true
+	}
+}
+
+func (m Mate) suffix() string {
+	switch m {
+	case Mate1:
+		return "/1"
+	case Mate2:
+		return "/2"
+	default:
+		return ""
+	}
+}
+
+// pairingSampleSize is the number of primary records inspected by IsPaired
+const pairingSampleSize = 1000
+
+// IsPaired reports whether the reads in a BAM file are paired end based on
+// the flags of the first records. Paired reads sorted by coordinate are an
+// error, their mates would be written to the R1 and R2 files out of order.
+// reference is only used for CRAM files, see Open.
+func IsPaired(path string, reference string) (bool, error) {
+	f, err := Open(path, reference)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	reader, err := NewReader(f)
+	if err != nil {
+		return false, err
+	}
+
+	paired, unpaired := 0, 0
+	for paired+unpaired < pairingSampleSize {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return false, err
+		}
+		if !record.IsPrimary() {
+			continue
+		}
+		if record.Flag&FlagPaired != 0 {
+			paired++
+		} else {
+			unpaired++
+		}
+	}
+	if paired > 0 && unpaired > 0 {
+		return false, errors.New("BAM file " + path + " mixes paired and unpaired reads")
+	}
+	if paired > 0 && reader.SortOrder == SortOrderCoordinate {
+		return false, fmt.Errorf("BAM file %s is sorted by coordinate so the mates of its paired reads are out of order, group them with `samtools collate` first", path)
+	}
+	return paired > 0, nil
+}
+
+func writeFASTQ(w *bufio.Writer, r Record, mate Mate) error {
+	for _, s := range []string{"@", r.Name, mate.suffix(), "\n"} {
+		if _, err := w.WriteString(s); err != nil {
+			return err
+		}
+	}
+	if _, err := w.Write(r.Seq); err != nil {
+		return err
+	}
+	if _, err := w.WriteString("\n+\n"); err != nil {
+		return err
+	}
+	if _, err := w.Write(r.Qual); err != nil {
+		return err
+	}
+	return w.WriteByte('\n')
+}
+
+// orphanError is the error for a paired read whose mate is not next to it
+func orphanError(path string, r Record) error {
+	return fmt.Errorf("read %s of BAM file %s has no mate next to it so its R1 and R2 files would be out of sync, remove unpaired reads and group mates with `samtools collate` first", r.Name, path)
+}
+
+// writeBAMFASTQ writes the selected reads of one BAM file as FASTQ
+func writeBAMFASTQ(w *bufio.Writer, path string, reference string, mate Mate) error {
+	f, err := Open(path, reference)
+	if err != nil {
+		return err
+	}
+	reader, err := NewReader(f)
+	if err == nil {
+		err = writeRecordsFASTQ(w, reader, path, mate)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// writeRecordsFASTQ writes the selected records of reader as FASTQ. The mates
+// of paired reads must be next to each other, every READ1 is checked for a
+// READ2 with the same name so the R1 and R2 files written from the same BAM
+// file stay in sync.
+func writeRecordsFASTQ(w *bufio.Writer, reader *Reader, path string, mate Mate) error {
+	var pending *Record
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if !record.IsPrimary() {
+			continue
+		}
+		if mate == MateSingle {
+			if err := writeFASTQ(w, record, mate); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if record.Flag&FlagPaired == 0 || record.Flag&(FlagRead1|FlagRead2) == 0 {
+			return orphanError(path, record)
+		}
+		if pending == nil {
+			pending = &record
+			continue
+		}
+		if pending.Name != record.Name || pending.Flag&(FlagRead1|FlagRead2) == record.Flag&(FlagRead1|FlagRead2) {
+			return orphanError(path, *pending)
+		}
+		for _, r := range []Record{*pending, record} {
+			if mate.matches(r) {
+				if err := writeFASTQ(w, r, mate); err != nil {
+					return err
+				}
+			}
+		}
+		pending = nil
+	}
+	if pending != nil {
+		return orphanError(path, *pending)
+	}
+	return nil
+}
+
+func writeGzippedFASTQ(w io.Writer, paths []string, reference string, mate Mate) error {
+	gz := gzip.NewWriter(w)
+	bw := bufio.NewWriter(gz)
+	for _, path := range paths {
+		if err := writeBAMFASTQ(bw, path, reference, mate); err != nil {
+			return err
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// GzippedFASTQ streams the selected reads of one or more BAM files as a
+// single gzipped FASTQ. The conversion runs as the returned reader is read so
+// nothing is written to disk. Reading fails if a paired read has no mate.
+// reference is only used for CRAM files, see Open.
+func GzippedFASTQ(paths []string, reference string, mate Mate) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(writeGzippedFASTQ(pw, paths, reference, mate))
+	}()
+	return pr
+}
@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/spf13/viper"
@@ -34,6 +35,12 @@ var DefaultClient = &Client{
 	httpClient: http.DefaultClient,
 }
 
+// NewClient returns a client that authenticates with auth0Client and sends
+// requests with httpClient
+func NewClient(auth0Client auth0.Auth0, httpClient HTTPClient) *Client {
+	return &Client{auth0: auth0Client, httpClient: httpClient}
+}
+
 func (c *Client) authorizedRequest(req *http.Request) (*http.Response, error) {
 	token, err := c.auth0.IDToken()
 	if err != nil {
@@ -162,15 +169,15 @@ func (c *Client) GetProjectID(projectName string) (int, error) {
 type GetGeoSearchSuggestionReq struct{}
 
 type GeoSearchSuggestion struct {
-	Name            string `json:"name"`
-	GeoLevel        string `json:"geo_level"`
-	CountryName     string `json:"country_name"`
-	StateName       string `json:"state_name"`
-	SubdivisionName string `json:"subdivision_name"`
-	CityName        string `json:"city_name"`
+	Name            string `json:"name" yaml:"name"`
+	GeoLevel        string `json:"geo_level" yaml:"geo_level"`
+	CountryName     string `json:"country_name" yaml:"country_name"`
+	StateName       string `json:"state_name" yaml:"state_name"`
+	SubdivisionName string `json:"subdivision_name" yaml:"subdivision_name"`
+	CityName        string `json:"city_name" yaml:"city_name"`
 	// Lat             float64 `json:"lat"`
 	// Lng             float64 `json:"lng"`
-	CountryCode string `json:"country_code"`
+	CountryCode string `json:"country_code" yaml:"country_code"`
 	// OSMID           int64   `json:"osm_id"`
 	// OSMType         string  `json:"osm_type"`
 	// LocationID      int64   `json:"locationiq_id"`
@@ -193,8 +200,41 @@ func (g GeoSearchSuggestion) String() string {
 	return strings.Join(places, ", ")
 }
 
-func (c *Client) GetGeoSearchSuggestion(queryStr string, isHuman bool) (GeoSearchSuggestion, error) {
-	query := url.Values{"query": []string{queryStr}, "limit": []string{"1"}}
+// humanizeSuggestion removes the city from a location so human samples are
+// not located more precisely than their subdivision
+func humanizeSuggestion(result GeoSearchSuggestion) GeoSearchSuggestion {
+	if result.GeoLevel != "city" {
+		return result
+	}
+	if result.SubdivisionName == result.CityName {
+		result.SubdivisionName = ""
+	}
+
+	result.CityName = ""
+	result.Name = ""
+	for _, s := range []string{result.SubdivisionName, result.StateName, result.CountryName} {
+		if s != "" {
+			if len(result.Name) > 0 {
+				result.Name += ", "
+			}
+			result.Name += s
+		}
+	}
+
+	if result.SubdivisionName != "" {
+		result.GeoLevel = "subdivision"
+	} else if result.StateName != "" {
+		result.GeoLevel = "state"
+	} else if result.CountryName != "" {
+		result.GeoLevel = "country"
+	}
+	return result
+}
+
+// GetGeoSearchSuggestions returns up to limit locations matching queryStr,
+// best match first. Locations are not truncated for human samples.
+func (c *Client) GetGeoSearchSuggestions(queryStr string, limit int) ([]GeoSearchSuggestion, error) {
+	query := url.Values{"query": []string{queryStr}, "limit": []string{strconv.Itoa(limit)}}
 	resp := []GeoSearchSuggestion{}
 	err := c.request(
 		"GET",
@@ -203,33 +243,17 @@ func (c *Client) GetGeoSearchSuggestion(queryStr string, isHuman bool) (GeoSearc
 		GetGeoSearchSuggestionReq{},
 		&resp,
 	)
+	return resp, err
+}
+
+func (c *Client) GetGeoSearchSuggestion(queryStr string, isHuman bool) (GeoSearchSuggestion, error) {
+	resp, err := c.GetGeoSearchSuggestions(queryStr, 1)
 	result := GeoSearchSuggestion{}
 	if len(resp) > 0 {
 		result = resp[0]
 	}
-	if isHuman && result.GeoLevel == "city" {
-		if result.SubdivisionName == result.CityName {
-			result.SubdivisionName = ""
-		}
-
-		result.CityName = ""
-		result.Name = ""
-		for _, s := range []string{result.SubdivisionName, result.StateName, result.CountryName} {
-			if s != "" {
-				if len(result.Name) > 0 {
-					result.Name += ", "
-				}
-				result.Name += s
-			}
-		}
-
-		if result.SubdivisionName != "" {
-			result.GeoLevel = "subdivision"
-		} else if result.StateName != "" {
-			result.GeoLevel = "state"
-		} else if result.CountryName != "" {
-			result.GeoLevel = "country"
-		}
+	if isHuman {
+		result = humanizeSuggestion(result)
 	}
 
 	return result, err
@@ -0,0 +1,177 @@
+package czid
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// orders of the day and month in numeric dates like 3/4/22
+const (
+	DateOrderMonthFirst = "mdy"
+	DateOrderDayFirst   = "dmy"
+)
+
+// DateOrders are the supported date orders
+var DateOrders = []string{DateOrderMonthFirst, DateOrderDayFirst}
+
+// DateOptions control how metadata dates are normalized
+type DateOptions struct {
+	// Order is one of DateOrders, it is only used for dates where the day and
+	// month are both numbers and the year comes last
+	Order string
+}
+
+type dateLayout struct {
+	layout string
+	hasDay bool
+}
+
+// unambiguousDateLayouts are date formats that can be parsed regardless of
+// the date order
+var unambiguousDateLayouts = []dateLayout{
+	{"2006-1-2", true},
+	{"2006/1/2", true},
+	{"2006.1.2", true},
+	{"2006-1", false},
+	{"2006/1", false},
+	{"2006-1-2T15:04", true},
+	{"2006-1-2T15:04:05", true},
+	{"2006-1-2 15:04", true},
+	{"2006-1-2 15:04:05", true},
+	{time.RFC3339, true},
+	{"2006-01-02T15:04:05.999999999", true},
+	{"20060102", true},
+	{"1/2006", false},
+	{"1-2006", false},
+	{"2 Jan 2006", true},
+	{"2 January 2006", true},
+	{"2-Jan-2006", true},
+	{"2-Jan-06", true},
+	{"2 Jan 06", true},
+	{"Jan 2 2006", true},
+	{"Jan 2, 2006", true},
+	{"January 2 2006", true},
+	{"January 2, 2006", true},
+	{"Jan 2006", false},
+	{"January 2006", false},
+	{"Jan-2006", false},
+	{"Jan-06", false},
+}
+
+// orderedDateLayouts are numeric date formats that depend on the date order
+var orderedDateLayouts = map[string][]dateLayout{
+	DateOrderMonthFirst: {
+		{"1/2/2006", true},
+		{"1-2-2006", true},
+		{"1.2.2006", true},
+		{"1/2/06", true},
+		{"1-2-06", true},
+		{"1.2.06", true},
+	},
+	DateOrderDayFirst: {
+		{"2/1/2006", true},
+		{"2-1-2006", true},
+		{"2.1.2006", true},
+		{"2/1/06", true},
+		{"2-1-06", true},
+		{"2.1.06", true},
+	},
+}
+
+// NormalizeDate parses a date in one of many common formats and formats it
+// as YYYY-MM-DD, or YYYY-MM if it has no day or truncate is true. ok is false
+// if the date could not be parsed.
+func NormalizeDate(value string, order string, truncate bool) (string, bool) {
+	value = strings.TrimSpace(value)
+	if order == "" {
+		order = DateOrderMonthFirst
+	}
+	layouts := append(append([]dateLayout{}, unambiguousDateLayouts...), orderedDateLayouts[order]...)
+	for _, l := range layouts {
+		t, err := time.Parse(l.layout, value)
+		if err != nil {
+			continue
+		}
+		if !l.hasDay || truncate {
+			return t.Format("2006-01"), true
+		}
+		return t.Format("2006-01-02"), true
+	}
+	return value, false
+}
+
+// DateChange is a metadata date that was normalized
+type DateChange struct {
+	SampleName string
+	Column     string
+	From       string
+	To         string
+}
+
+func (c DateChange) String() string {
+	return fmt.Sprintf("  replacing %s \"%s\" with \"%s\" for sample '%s'", c.Column, c.From, c.To, c.SampleName)
+}
+
+// isDateColumn reports whether a metadata column holds dates according to
+// the schemas, collection date is always treated as a date
+func isDateColumn(column string, dateHeaders map[string]bool) bool {
+	normalized := normalizeHeader(column)
+	return normalized == "collection date" || dateHeaders[normalized]
+}
+
+// NormalizeDates normalizes the date columns of samples' metadata to CZ ID's
+// date format in place. Dates of human samples are truncated to the month,
+// the same way their collection locations are truncated. Values that can't be
+// parsed are left for validation to report.
+func NormalizeDates(samplesMetadata SamplesMetadata, schemas map[string][]MetadataField, options DateOptions) []DateChange {
+	dateHeaders := map[string]bool{}
+	for _, fields := range schemas {
+		for _, f := range fields {
+			if f.DataType == "date" {
+				dateHeaders[normalizeHeader(f.Name)] = true
+				if f.Key != "" {
+					dateHeaders[normalizeHeader(f.Key)] = true
+				}
+			}
+		}
+	}
+
+	sampleNames := make([]string, 0, len(samplesMetadata))
+	for sampleName := range samplesMetadata {
+		sampleNames = append(sampleNames, sampleName)
+	}
+	sort.Strings(sampleNames)
+
+	changes := []DateChange{}
+	for _, sampleName := range sampleNames {
+		m := samplesMetadata[sampleName]
+		columns := make([]string, 0, len(m.fields))
+		for column := range m.fields {
+			columns = append(columns, column)
+		}
+		sort.Strings(columns)
+		for _, column := range columns {
+			value := m.fields[column]
+			if value == "" || !isDateColumn(column, dateHeaders) {
+				continue
+			}
+			normalized, ok := NormalizeDate(value, options.Order, m.isHuman())
+			if !ok || normalized == value {
+				continue
+			}
+			m.fields[column] = normalized
+			changes = append(changes, DateChange{SampleName: sampleName, Column: column, From: value, To: normalized})
+		}
+	}
+	return changes
+}
+
+// PrintDateChanges prints normalized dates to w
+func PrintDateChanges(w io.Writer, changes []DateChange) {
+	for _, c := range changes {
+		fmt.Fprintln(w, c)
+	}
+}
@@ -0,0 +1,68 @@
+package czid
+
+import "testing"
+
+func TestNormalizeDate(t *testing.T) {
+	cases := []struct {
+		value    string
+		order    string
+		truncate bool
+		expected string
+		ok       bool
+	}{
+		{"2022-03-04", DateOrderMonthFirst, false, "2022-03-04", true},
+		{"2022-3-4", DateOrderMonthFirst, false, "2022-03-04", true},
+		{"2022-03", DateOrderMonthFirst, false, "2022-03", true},
+		{"2022-03-04T10:00", DateOrderMonthFirst, false, "2022-03-04", true},
+		{"2022-03-04T10:00:00Z", DateOrderMonthFirst, false, "2022-03-04", true},
+		{"3/4/22", DateOrderMonthFirst, false, "2022-03-04", true},
+		{"3/4/22", DateOrderDayFirst, false, "2022-04-03", true},
+		{"04.03.2022", DateOrderDayFirst, false, "2022-03-04", true},
+		{"04 Mar 2022", DateOrderDayFirst, false, "2022-03-04", true},
+		{"4 march 2022", DateOrderMonthFirst, false, "2022-03-04", true},
+		{"March 4, 2022", DateOrderMonthFirst, false, "2022-03-04", true},
+		{"Mar 2022", DateOrderMonthFirst, false, "2022-03", true},
+		{"03/2022", DateOrderMonthFirst, false, "2022-03", true},
+		{"2022-03-04", DateOrderMonthFirst, true, "2022-03", true},
+		{"3/4/22", DateOrderMonthFirst, true, "2022-03", true},
+		{"13/4/22", DateOrderMonthFirst, false, "13/4/22", false},
+		{"last spring", DateOrderMonthFirst, false, "last spring", false},
+	}
+	for _, c := range cases {
+		normalized, ok := NormalizeDate(c.value, c.order, c.truncate)
+		if normalized != c.expected || ok != c.ok {
+			t.Errorf("NormalizeDate(%q, %s, %v) = %q, %v but expected %q, %v", c.value, c.order, c.truncate, normalized, ok, c.expected, c.ok)
+		}
+	}
+}
+
+func TestNormalizeDates(t *testing.T) {
+	samplesMetadata := SamplesMetadata{
+		"human": NewMetadata(map[string]string{
+			"Host Organism":   "Human",
+			"Collection Date": "3/4/22",
+		}),
+		"mosquito": NewMetadata(map[string]string{
+			"Host Organism":   "Mosquito",
+			"collection_date": "3/4/22",
+			"Ct Value":        "3/4/22",
+		}),
+	}
+	schemas := map[string][]MetadataField{
+		"mosquito": {{Key: "collection_date", Name: "Collection Date", DataType: "date"}},
+	}
+
+	changes := NormalizeDates(samplesMetadata, schemas, DateOptions{Order: DateOrderDayFirst})
+	if len(changes) != 2 {
+		t.Errorf("expected 2 dates to be normalized but got %+v", changes)
+	}
+	if date := samplesMetadata["human"].fields["Collection Date"]; date != "2022-04" {
+		t.Errorf("expected the human collection date to be truncated to 2022-04 but it was %s", date)
+	}
+	if date := samplesMetadata["mosquito"].fields["collection_date"]; date != "2022-04-03" {
+		t.Errorf("expected the mosquito collection date to be 2022-04-03 but it was %s", date)
+	}
+	if value := samplesMetadata["mosquito"].fields["Ct Value"]; value != "3/4/22" {
+		t.Errorf("expected a column that isn't a date to be unchanged but it was %s", value)
+	}
+}
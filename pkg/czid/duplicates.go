@@ -0,0 +1,143 @@
+package czid
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// fingerprintBlockSize is the size of each block hashed by a sampled
+// fingerprint
+const fingerprintBlockSize = 64 * 1024
+
+// Fingerprint identifies the content of a file. By default only the first,
+// middle, and last blocks are hashed so fingerprinting large files is fast.
+type Fingerprint struct {
+	Size int64
+	Hash string
+}
+
+// FingerprintFile computes the fingerprint of a file, hashing the whole file
+// if fullHash is true or if the file is smaller than three blocks
+func FingerprintFile(path string, fullHash bool) (Fingerprint, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Fingerprint{}, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return Fingerprint{}, err
+	}
+	size := info.Size()
+
+	h := sha256.New()
+	if fullHash || size <= 3*fingerprintBlockSize {
+		if _, err := io.Copy(h, f); err != nil {
+			return Fingerprint{}, err
+		}
+	} else {
+		for _, offset := range []int64{0, size/2 - fingerprintBlockSize/2, size - fingerprintBlockSize} {
+			if _, err := io.Copy(h, io.NewSectionReader(f, offset, fingerprintBlockSize)); err != nil {
+				return Fingerprint{}, err
+			}
+		}
+	}
+	return Fingerprint{Size: size, Hash: hex.EncodeToString(h.Sum(nil))}, nil
+}
+
+// DuplicateFile is an input file whose content matches another input file
+type DuplicateFile struct {
+	SampleName string
+	Read       string
+	Path       string
+}
+
+// DuplicateGroup is a set of input files with identical fingerprints
+type DuplicateGroup struct {
+	Fingerprint Fingerprint
+	Files       []DuplicateFile
+}
+
+func (g DuplicateGroup) String() string {
+	if len(g.Files) == 2 && g.Files[0].SampleName == g.Files[1].SampleName &&
+		g.Files[0].Read != g.Files[1].Read {
+		return fmt.Sprintf(
+			"%s and %s files of sample '%s' are identical: %s, %s",
+			g.Files[0].Read, g.Files[1].Read, g.Files[0].SampleName, g.Files[0].Path, g.Files[1].Path,
+		)
+	}
+	descriptions := make([]string, len(g.Files))
+	for i, f := range g.Files {
+		descriptions[i] = fmt.Sprintf("%s (sample '%s' %s)", f.Path, f.SampleName, f.Read)
+	}
+	return fmt.Sprintf("identical files: %s", strings.Join(descriptions, ", "))
+}
+
+// FindDuplicateContent fingerprints every read file of every sample and
+// returns the groups of files with identical content. Empty files are skipped.
+func FindDuplicateContent(samples map[string]SampleFiles, fullHash bool) ([]DuplicateGroup, error) {
+	groups := map[Fingerprint][]DuplicateFile{}
+	for sampleName, files := range samples {
+		reads := []struct {
+			name  string
+			paths []string
+		}{{"R1", files.R1}, {"R2", files.R2}, {"single", files.Single}, {"bam", files.BAM}}
+		for _, read := range reads {
+			for _, path := range read.paths {
+				fingerprint, err := FingerprintFile(path, fullHash)
+				if err != nil {
+					return nil, err
+				}
+				if fingerprint.Size == 0 {
+					continue
+				}
+				groups[fingerprint] = append(groups[fingerprint], DuplicateFile{
+					SampleName: sampleName,
+					Read:       read.name,
+					Path:       path,
+				})
+			}
+		}
+	}
+
+	duplicates := []DuplicateGroup{}
+	for fingerprint, files := range groups {
+		if len(files) < 2 {
+			continue
+		}
+		sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+		duplicates = append(duplicates, DuplicateGroup{Fingerprint: fingerprint, Files: files})
+	}
+	sort.Slice(duplicates, func(i, j int) bool {
+		return duplicates[i].Files[0].Path < duplicates[j].Files[0].Path
+	})
+	return duplicates, nil
+}
+
+// CheckDuplicateContent prints any input files with identical content and
+// returns an error if there are any unless allowDuplicates is true
+func CheckDuplicateContent(samples map[string]SampleFiles, fullHash bool, allowDuplicates bool) error {
+	duplicates, err := FindDuplicateContent(samples, fullHash)
+	if err != nil {
+		return err
+	}
+	if len(duplicates) == 0 {
+		return nil
+	}
+
+	fmt.Printf("found %d sets of input files with identical content:\n", len(duplicates))
+	for _, d := range duplicates {
+		fmt.Printf("  %s\n", d)
+	}
+	if allowDuplicates {
+		fmt.Println("uploading anyway because --allow-duplicate-content is set")
+		return nil
+	}
+	return fmt.Errorf("found input files with identical content, if this is intentional use --allow-duplicate-content")
+}
@@ -0,0 +1,99 @@
+package czid
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestFindDuplicateContent(t *testing.T) {
+	dirname, err := os.MkdirTemp(".", "samples")
+	defer os.RemoveAll(dirname)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{
+		"ABC_R1.fastq": "@r1\nACGT\n+\nIIII\n",
+		"ABC_R2.fastq": "@r1\nACGT\n+\nIIII\n",
+		"DEF.fastq":    "@r1\nTTTT\n+\nIIII\n",
+		"GHI.fastq":    "@r1\nTTTT\n+\nIIII\n",
+		"JKL.fastq":    "@r1\nGGGG\n+\nIIII\n",
+		"MNO.fastq":    "",
+		"PQR.fastq":    "",
+	}
+	for filename, contents := range files {
+		err := os.WriteFile(path.Join(dirname, filename), []byte(contents), fs.ModePerm)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	samples, err := SamplesFromDir(dirname, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	duplicates, err := FindDuplicateContent(samples, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(duplicates) != 2 {
+		t.Fatalf("expected 2 duplicate groups but got %d: %v", len(duplicates), duplicates)
+	}
+
+	if !strings.HasPrefix(duplicates[0].String(), "R1 and R2 files of sample 'ABC' are identical") {
+		t.Errorf("unexpected description of identical R1 and R2: %s", duplicates[0])
+	}
+
+	if duplicates[1].Files[0].SampleName != "DEF" || duplicates[1].Files[1].SampleName != "GHI" {
+		t.Errorf("expected DEF and GHI to be identical but got %s", duplicates[1])
+	}
+}
+
+func TestFingerprintFileSampledBlocks(t *testing.T) {
+	dirname, err := os.MkdirTemp(".", "samples")
+	defer os.RemoveAll(dirname)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contents := make([]byte, 4*fingerprintBlockSize)
+	a := path.Join(dirname, "a.fastq")
+	if err := os.WriteFile(a, contents, fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	// differs only outside of the sampled blocks
+	contents[fingerprintBlockSize+1] = 1
+	b := path.Join(dirname, "b.fastq")
+	if err := os.WriteFile(b, contents, fs.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	fa, err := FingerprintFile(a, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fb, err := FingerprintFile(b, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fa != fb {
+		t.Error("expected sampled fingerprints to match")
+	}
+
+	fa, err = FingerprintFile(a, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fb, err = FingerprintFile(b, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fa == fb {
+		t.Error("expected full hash fingerprints to differ")
+	}
+}
@@ -0,0 +1,108 @@
+package czid
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/chanzuckerberg/czid-cli/pkg/xlsx"
+)
+
+// MetadataFormats are the formats metadata can be exported to
+var MetadataFormats = []string{"csv", "tsv", "xlsx", "json"}
+
+// MetadataFormatFromPath returns the metadata format for a file's extension
+// or "" if the extension isn't a metadata format
+func MetadataFormatFromPath(path string) string {
+	ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	for _, format := range MetadataFormats {
+		if ext == format {
+			return format
+		}
+	}
+	return ""
+}
+
+// ProjectMetadataRows converts project samples' metadata to rows with a
+// header row first. The columns are "Sample Name", optionally "Sample ID",
+// "Host Organism", then every metadata field in alphabetical order, so the
+// rows can be read back by ReadMetadataFile.
+func ProjectMetadataRows(samples []ProjectSample, includeIDs bool) [][]string {
+	columnSet := map[string]bool{}
+	for _, s := range samples {
+		for column := range s.Metadata {
+			columnSet[column] = true
+		}
+	}
+	columns := make([]string, 0, len(columnSet))
+	for column := range columnSet {
+		if !isHostGenomeHeader(column) && column != "Sample Name" && column != "Sample ID" {
+			columns = append(columns, column)
+		}
+	}
+	sort.Strings(columns)
+
+	headers := []string{"Sample Name"}
+	if includeIDs {
+		headers = append(headers, "Sample ID")
+	}
+	headers = append(headers, "Host Organism")
+	headers = append(headers, columns...)
+
+	rows := make([][]string, 0, len(samples)+1)
+	rows = append(rows, headers)
+	for _, s := range samples {
+		row := []string{s.Name}
+		if includeIDs {
+			row = append(row, strconv.Itoa(s.ID))
+		}
+		row = append(row, s.HostGenome)
+		for _, column := range columns {
+			row = append(row, s.Metadata[column])
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// WriteMetadataRows writes rows with a header row first in one of
+// MetadataFormats. JSON is written as an array of objects without empty
+// values.
+func WriteMetadataRows(w io.Writer, format string, rows [][]string) error {
+	switch format {
+	case "csv", "tsv":
+		cw := csv.NewWriter(w)
+		if format == "tsv" {
+			cw.Comma = '\t'
+		}
+		if err := cw.WriteAll(rows); err != nil {
+			return err
+		}
+		return cw.Error()
+	case "xlsx":
+		return xlsx.Write(w, "Metadata", rows)
+	case "json":
+		objects := []map[string]string{}
+		if len(rows) > 0 {
+			for _, row := range rows[1:] {
+				object := make(map[string]string, len(row))
+				for i, value := range row {
+					if value != "" && i < len(rows[0]) {
+						object[rows[0][i]] = value
+					}
+				}
+				objects = append(objects, object)
+			}
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(objects)
+	default:
+		return fmt.Errorf("format \"%s\" not supported, please choose one of: %s", format, strings.Join(MetadataFormats, ", "))
+	}
+}
@@ -0,0 +1,46 @@
+package czid
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExportMetadataRoundTrip(t *testing.T) {
+	rows := ProjectMetadataRows(testProjectSamples, true)
+	expectedHeaders := []string{"Sample Name", "Sample ID", "Host Organism", "Collection Location", "Ct Value", "Notes", "Sample Type"}
+	if len(rows) != 3 || len(rows[0]) != len(expectedHeaders) {
+		t.Fatalf("expected a header row and 2 samples with %v but got %v", expectedHeaders, rows)
+	}
+	for i, header := range expectedHeaders {
+		if rows[0][i] != header {
+			t.Errorf("expected column %d to be %s but it was %s", i, header, rows[0][i])
+		}
+	}
+
+	dir := t.TempDir()
+	for _, format := range MetadataFormats {
+		path := filepath.Join(dir, "metadata."+format)
+		f, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = WriteMetadataRows(f, format, rows)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		samplesMetadata, err := ReadMetadataFile(path, MetadataReadOptions{})
+		if err != nil {
+			t.Fatalf("%s: %s", format, err)
+		}
+		matched, unmatched := MatchProjectSamples(samplesMetadata, testProjectSamples)
+		if len(unmatched) > 0 {
+			t.Errorf("%s: expected every sample to match but %v did not", format, unmatched)
+		}
+		if changes := DiffMetadata(testProjectSamples, matched, true); len(changes) > 0 {
+			t.Errorf("%s: expected no changes after a round trip but got %+v", format, changes)
+		}
+	}
+}
@@ -8,9 +8,27 @@ import (
 type getMetadataForHostGenomeReq struct{}
 
 type getMetadataForHostGenomeMetadataField struct {
-	DisplayName string `json:"display_name"`
-	Description string `json:"description"`
-	Examples    string `json:"examples"`
+	Key         string      `json:"key"`
+	DisplayName string      `json:"display_name"`
+	Description string      `json:"description"`
+	Examples    string      `json:"examples"`
+	IsRequired  interface{} `json:"is_required"`
+	DataType    string      `json:"data_type"`
+}
+
+// isTruthy interprets the is_required flag which may be a boolean or a 0/1
+// integer depending on the API version
+func isTruthy(v interface{}) bool {
+	switch v := v.(type) {
+	case bool:
+		return v
+	case float64:
+		return v != 0
+	case string:
+		return v == "1" || v == "true"
+	default:
+		return false
+	}
 }
 
 type Example struct {
@@ -19,9 +37,14 @@ type Example struct {
 }
 
 type MetadataField struct {
+	Key         string
 	Name        string
 	Description string
 	Example     Example
+	IsRequired  bool
+	// DataType is one of "string", "number", "date", "location", or "" if
+	// the API did not provide it
+	DataType string
 }
 
 func (c *Client) GetMetadataForHostGenome(hostGenome string) ([]MetadataField, error) {
@@ -46,9 +69,12 @@ func (c *Client) GetMetadataForHostGenome(hostGenome string) ([]MetadataField, e
 			return []MetadataField{}, err
 		}
 		metadataFields[i] = MetadataField{
+			Key:         f.Key,
 			Name:        f.DisplayName,
 			Description: f.Description,
 			Example:     ex,
+			IsRequired:  isTruthy(f.IsRequired),
+			DataType:    f.DataType,
 		}
 	}
 
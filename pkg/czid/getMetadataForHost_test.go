@@ -55,3 +55,32 @@ func TestGetMetadataForHostParsingError(t *testing.T) {
 		t.Errorf("expected error from invalid JSON  but error was nil")
 	}
 }
+
+func TestGetMetadataForHostRequiredAndDataType(t *testing.T) {
+	response := []byte(`[{
+      "key": "collection_date",
+      "display_name": "Collection Date",
+      "description": "desc",
+      "examples": "{}",
+      "is_required": 1,
+      "data_type": "date"
+    }]`)
+	httpClient := newMockHTTPClient(response)
+	apiClient := Client{
+		auth0:      &mockAuth0Client{},
+		httpClient: &httpClient,
+	}
+
+	fields, err := apiClient.GetMetadataForHostGenome("human")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !fields[0].IsRequired {
+		t.Error("expected field to be required")
+	}
+
+	if fields[0].DataType != "date" || fields[0].Key != "collection_date" {
+		t.Errorf("unexpected data type '%s' or key '%s'", fields[0].DataType, fields[0].Key)
+	}
+}
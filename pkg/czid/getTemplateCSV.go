@@ -8,15 +8,26 @@ import (
 )
 
 func (c *Client) GetTemplateCSV(sampleNames []string, hostGenome string) (*csv.Reader, error) {
+	hostGenomes := []string{}
+	if hostGenome != "" {
+		hostGenomes = make([]string, len(sampleNames))
+		for i := range sampleNames {
+			hostGenomes[i] = hostGenome
+		}
+	}
+	return c.GetTemplateCSVForHosts(sampleNames, hostGenomes)
+}
+
+// GetTemplateCSVForHosts gets a metadata template with the metadata fields of
+// each sample's host organism, hostGenomes[i] is the host organism of
+// sampleNames[i]. hostGenomes may be empty if no host organisms are known.
+func (c *Client) GetTemplateCSVForHosts(sampleNames []string, hostGenomes []string) (*csv.Reader, error) {
 	query := url.Values{
 		"new_sample_names[]": sampleNames,
 	}
 
-	if hostGenome != "" {
-		query["host_genomes[]"] = make([]string, len(sampleNames))
-		for i := range sampleNames {
-			query["host_genomes[]"][i] = hostGenome
-		}
+	if len(hostGenomes) > 0 {
+		query["host_genomes[]"] = hostGenomes
 	}
 
 	url := url.URL{
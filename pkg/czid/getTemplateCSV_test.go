@@ -44,3 +44,29 @@ func TestGetTemplateCSV(t *testing.T) {
 		t.Error("")
 	}
 }
+
+func TestGetTemplateCSVForHosts(t *testing.T) {
+	httpClient := newMockHTTPClient([]byte("Sample Name,Host Organism"))
+	apiClient := Client{
+		auth0:      &mockAuth0Client{},
+		httpClient: &httpClient,
+	}
+
+	_, err := apiClient.GetTemplateCSVForHosts([]string{"sample_1", "sample_2"}, []string{"Human", "Mosquito"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := httpClient.calls[0].URL.Query()
+	hostGenomes := query["host_genomes[]"]
+	if len(hostGenomes) != 2 || hostGenomes[0] != "Human" || hostGenomes[1] != "Mosquito" {
+		t.Errorf("expected a host organism for each sample, got %v", hostGenomes)
+	}
+
+	_, err = apiClient.GetTemplateCSVForHosts([]string{"sample_1"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, has := httpClient.calls[1].URL.Query()["host_genomes[]"]; has {
+		t.Errorf("expected no host organisms to be sent")
+	}
+}
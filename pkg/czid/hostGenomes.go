@@ -0,0 +1,157 @@
+package czid
+
+// This file is for looking up the host organisms CZ ID supports and checking
+// the host organisms of samples' metadata against them
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/chanzuckerberg/czid-cli/pkg/util"
+)
+
+const hostGenomesCacheName = "host_genomes.json"
+
+// HostGenome is a host organism supported by CZ ID
+type HostGenome struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	// ERCCOnly is true for host organisms without a host genome, only ERCC
+	// reads are filtered out of their samples
+	ERCCOnly bool `json:"ercc_only"`
+}
+
+type getHostGenomesReq struct{}
+
+type cachedHostGenomes struct {
+	FetchedAt   time.Time
+	HostGenomes []HostGenome
+}
+
+// GetHostGenomes returns the host organisms supported by CZ ID sorted by
+// name. Host organisms are fetched from CZ ID and cached, if offline is true
+// or CZ ID can't be reached the cached host organisms are used.
+func (c *Client) GetHostGenomes(offline bool) ([]HostGenome, error) {
+	if !offline {
+		var hostGenomes []HostGenome
+		err := c.request("GET", "/host_genomes.json", "", getHostGenomesReq{}, &hostGenomes)
+		if err == nil {
+			sort.Slice(hostGenomes, func(i, j int) bool {
+				return strings.ToLower(hostGenomes[i].Name) < strings.ToLower(hostGenomes[j].Name)
+			})
+			cacheErr := util.WriteJSONCache(hostGenomesCacheName, cachedHostGenomes{
+				FetchedAt:   time.Now(),
+				HostGenomes: hostGenomes,
+			})
+			if cacheErr != nil {
+				fmt.Fprintf(os.Stderr, "warning: could not cache host organisms: %s\n", cacheErr)
+			}
+			return hostGenomes, nil
+		}
+		fmt.Fprintf(os.Stderr, "warning: could not fetch host organisms, using cached host organisms: %s\n", err)
+	}
+
+	var cached cachedHostGenomes
+	found, err := util.ReadJSONCache(hostGenomesCacheName, &cached)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("no cached host organisms, run once while online to cache them")
+	}
+	return cached.HostGenomes, nil
+}
+
+// SuggestHostGenome returns the supported host organism closest to an
+// unknown host organism name, or false if none is close enough
+func SuggestHostGenome(hostGenome string, hostGenomes []HostGenome) (string, bool) {
+	normalized := normalizeHeader(hostGenome)
+	best, bestDistance, ambiguous := "", maxHeaderEdits(normalized)+1, false
+	for _, h := range hostGenomes {
+		distance := util.EditDistance(normalized, normalizeHeader(h.Name))
+		if distance < bestDistance {
+			best, bestDistance, ambiguous = h.Name, distance, false
+		} else if distance == bestDistance {
+			ambiguous = true
+		}
+	}
+	if best == "" || ambiguous {
+		return "", false
+	}
+	return best, true
+}
+
+// CheckHostGenomes reports samples whose host organism is not supported by
+// CZ ID, suggesting the closest supported host organism. Samples with unknown
+// host organisms are still created but only ERCC reads are filtered out of
+// them, so issues are warnings unless strict is true.
+func CheckHostGenomes(samplesMetadata SamplesMetadata, hostGenomes []HostGenome, strict bool) []MetadataIssue {
+	known := make(map[string]bool, len(hostGenomes))
+	for _, h := range hostGenomes {
+		known[normalizeHeader(h.Name)] = true
+	}
+	severity := IssueWarning
+	if strict {
+		severity = IssueError
+	}
+
+	sampleNames := make([]string, 0, len(samplesMetadata))
+	for sampleName := range samplesMetadata {
+		sampleNames = append(sampleNames, sampleName)
+	}
+	sort.Strings(sampleNames)
+
+	issues := []MetadataIssue{}
+	for _, sampleName := range sampleNames {
+		hostGenome := samplesMetadata[sampleName].HostGenome
+		if hostGenome == "" || known[normalizeHeader(hostGenome)] {
+			continue
+		}
+		caption := fmt.Sprintf("host organism '%s' not found, host filtering will only filter out ERCC reads", hostGenome)
+		if suggestion, ok := SuggestHostGenome(hostGenome, hostGenomes); ok {
+			caption = fmt.Sprintf("host organism '%s' not found, did you mean '%s'?", hostGenome, suggestion)
+		}
+		issues = append(issues, MetadataIssue{
+			Severity:   severity,
+			Caption:    caption,
+			SampleName: sampleName,
+			Column:     "Host Organism",
+			Value:      hostGenome,
+		})
+	}
+	return issues
+}
+
+// ReadHostGenomeMap reads the host organism of each sample from a metadata
+// file in any supported format. The host organism is read from column, or
+// from the usual host organism columns if column is empty. Samples without a
+// host organism are left out.
+func ReadHostGenomeMap(path string, column string, options MetadataReadOptions) (map[string]string, error) {
+	samplesMetadata, err := ReadMetadataFile(path, options)
+	if err != nil {
+		return nil, err
+	}
+	hostGenomes := make(map[string]string, len(samplesMetadata))
+	found := column == ""
+	for sampleName, m := range samplesMetadata {
+		hostGenome := m.HostGenome
+		if column != "" {
+			hostGenome = ""
+			for k, v := range m.fields {
+				if normalizeHeader(k) == normalizeHeader(column) {
+					hostGenome, found = strings.TrimSpace(v), true
+				}
+			}
+		}
+		if hostGenome != "" {
+			hostGenomes[sampleName] = hostGenome
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("host organism column '%s' not found in %s", column, path)
+	}
+	return hostGenomes, nil
+}
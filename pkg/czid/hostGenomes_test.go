@@ -0,0 +1,137 @@
+package czid
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var testHostGenomes = []HostGenome{
+	{ID: 1, Name: "Human"},
+	{ID: 2, Name: "Mosquito"},
+	{ID: 3, Name: "Mouse"},
+	{ID: 4, Name: "Tick", ERCCOnly: true},
+}
+
+func TestGetHostGenomes(t *testing.T) {
+	cacheHome := os.Getenv("XDG_CACHE_HOME")
+	defer os.Setenv("XDG_CACHE_HOME", cacheHome)
+	os.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	httpClient := newMockHTTPClient([]byte(`[
+		{"id": 2, "name": "mosquito", "ercc_only": false},
+		{"id": 1, "name": "Human", "ercc_only": false},
+		{"id": 4, "name": "Tick", "ercc_only": true}
+	]`))
+	client := Client{auth0: &mockAuth0Client{}, httpClient: &httpClient}
+
+	hostGenomes, err := client.GetHostGenomes(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hostGenomes) != 3 || hostGenomes[0].Name != "Human" || hostGenomes[1].Name != "mosquito" {
+		t.Fatalf("expected host organisms sorted by name, got %v", hostGenomes)
+	}
+	if !hostGenomes[2].ERCCOnly {
+		t.Errorf("expected Tick to be ERCC only")
+	}
+
+	cached, err := client.GetHostGenomes(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(httpClient.calls) != 1 {
+		t.Errorf("expected offline lookup to use the cache, got %d requests", len(httpClient.calls))
+	}
+	if len(cached) != 3 || cached[2].ID != 4 {
+		t.Errorf("expected cached host organisms, got %v", cached)
+	}
+}
+
+func TestGetHostGenomesOfflineWithoutCache(t *testing.T) {
+	cacheHome := os.Getenv("XDG_CACHE_HOME")
+	defer os.Setenv("XDG_CACHE_HOME", cacheHome)
+	os.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	httpClient := newMockHTTPClient([]byte(`[]`))
+	client := Client{auth0: &mockAuth0Client{}, httpClient: &httpClient}
+	if _, err := client.GetHostGenomes(true); err == nil {
+		t.Error("expected an error without cached host organisms")
+	}
+}
+
+func TestSuggestHostGenome(t *testing.T) {
+	tests := []struct {
+		hostGenome string
+		suggestion string
+		ok         bool
+	}{
+		{"Humen", "Human", true},
+		{"mosqito", "Mosquito", true},
+		{"Tik", "", false},
+		{"Mose", "", false},
+		{"Zebrafish", "", false},
+	}
+	for _, test := range tests {
+		suggestion, ok := SuggestHostGenome(test.hostGenome, testHostGenomes)
+		if suggestion != test.suggestion || ok != test.ok {
+			t.Errorf("expected %s to suggest '%s' (%v), got '%s' (%v)", test.hostGenome, test.suggestion, test.ok, suggestion, ok)
+		}
+	}
+}
+
+func TestCheckHostGenomes(t *testing.T) {
+	samplesMetadata := SamplesMetadata{
+		"sample_1": Metadata{HostGenome: "human"},
+		"sample_2": Metadata{HostGenome: "Humen"},
+		"sample_3": Metadata{HostGenome: "Zebrafish"},
+		"sample_4": Metadata{},
+	}
+
+	issues := CheckHostGenomes(samplesMetadata, testHostGenomes, false)
+	if len(issues) != 2 {
+		t.Fatalf("expected 2 issues, got %v", issues)
+	}
+	if issues[0].SampleName != "sample_2" || issues[0].Severity != IssueWarning || issues[0].Value != "Humen" {
+		t.Errorf("unexpected issue %v", issues[0])
+	}
+	if issues[0].Caption != "host organism 'Humen' not found, did you mean 'Human'?" {
+		t.Errorf("unexpected caption %s", issues[0].Caption)
+	}
+	if issues[1].SampleName != "sample_3" || issues[1].Caption != "host organism 'Zebrafish' not found, host filtering will only filter out ERCC reads" {
+		t.Errorf("unexpected issue %v", issues[1])
+	}
+
+	issues = CheckHostGenomes(samplesMetadata, testHostGenomes, true)
+	if errorCount, _ := CountIssues(issues); errorCount != 2 {
+		t.Errorf("expected strict host checks to report errors, got %v", issues)
+	}
+}
+
+func TestReadHostGenomeMap(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sample_sheet.csv")
+	contents := "Sample Name,Host Organism,Species\nsample_1,Human,Homo sapiens\nsample_2,Mosquito,Culex\nsample_3,,\n"
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	hostGenomes, err := ReadHostGenomeMap(path, "", MetadataReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hostGenomes) != 2 || hostGenomes["sample_1"] != "Human" || hostGenomes["sample_2"] != "Mosquito" {
+		t.Errorf("unexpected host organisms %v", hostGenomes)
+	}
+
+	hostGenomes, err = ReadHostGenomeMap(path, "species", MetadataReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hostGenomes) != 2 || hostGenomes["sample_2"] != "Culex" {
+		t.Errorf("unexpected host organisms from column %v", hostGenomes)
+	}
+
+	if _, err := ReadHostGenomeMap(path, "Host", MetadataReadOptions{}); err == nil {
+		t.Error("expected an error for a missing column")
+	}
+}
@@ -0,0 +1,385 @@
+package czid
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/chanzuckerberg/czid-cli/pkg/bam"
+)
+
+// maxLineLength bounds the length of a single FASTA/FASTQ line, long read
+// platforms can produce reads of several megabases
+const maxLineLength = 64 * 1024 * 1024
+
+// LengthDistribution summarizes the read lengths of one or more files
+type LengthDistribution struct {
+	Min    int     `json:"min"`
+	Median int     `json:"median"`
+	Mean   float64 `json:"mean"`
+	Max    int     `json:"max"`
+	counts map[int]int64
+}
+
+func (d *LengthDistribution) add(length int) {
+	if d.counts == nil {
+		d.counts = map[int]int64{}
+	}
+	d.counts[length]++
+}
+
+func (d *LengthDistribution) merge(o LengthDistribution) {
+	for length, count := range o.counts {
+		if d.counts == nil {
+			d.counts = map[int]int64{}
+		}
+		d.counts[length] += count
+	}
+}
+
+func (d *LengthDistribution) summarize() {
+	lengths := make([]int, 0, len(d.counts))
+	total := int64(0)
+	bases := int64(0)
+	for length, count := range d.counts {
+		lengths = append(lengths, length)
+		total += count
+		bases += int64(length) * count
+	}
+	if total == 0 {
+		return
+	}
+	sort.Ints(lengths)
+	d.Min = lengths[0]
+	d.Max = lengths[len(lengths)-1]
+	d.Mean = float64(bases) / float64(total)
+	seen := int64(0)
+	for _, length := range lengths {
+		seen += d.counts[length]
+		if seen*2 >= total {
+			d.Median = length
+			break
+		}
+	}
+}
+
+// FileStats are the statistics of a single input file
+type FileStats struct {
+	Path             string             `json:"path"`
+	CompressedSize   int64              `json:"compressed_size"`
+	UncompressedSize int64              `json:"uncompressed_size"`
+	Reads            int64              `json:"reads"`
+	ReadLength       LengthDistribution `json:"read_length"`
+	// MeanQuality is nil if no read has qualities, like FASTA files and BAM
+	// records stored without qualities
+	MeanQuality  *float64 `json:"mean_quality"`
+	Platform     string   `json:"platform"`
+	qualitySum   int64
+	qualityCount int64
+}
+
+// SampleStats are the statistics of all of a sample's input files
+type SampleStats struct {
+	Name             string             `json:"name"`
+	Pairing          string             `json:"pairing"`
+	Lanes            []int              `json:"lanes"`
+	CompressedSize   int64              `json:"compressed_size"`
+	UncompressedSize int64              `json:"uncompressed_size"`
+	Reads            int64              `json:"reads"`
+	ReadLength       LengthDistribution `json:"read_length"`
+	MeanQuality      *float64           `json:"mean_quality"`
+	Platform         string             `json:"platform"`
+	Files            []FileStats        `json:"files"`
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// pacBioReadNameExp matches PacBio read names, which start with the movie
+// name, ex. @m64011_190830_220126/1/ccs
+var pacBioReadNameExp = regexp.MustCompile(`^[@>]m\d+[a-zA-Z]?_\d{6}_\d{6}/`)
+
+// longReadLength is the mean read length above which reads without a
+// recognized header are reported as long reads
+const longReadLength = 1000
+
+// detectPlatform detects the sequencing platform from the header of the first
+// read. Long reads can come from several platforms so read length alone only
+// says they are long reads.
+func detectPlatform(header []byte, meanLength float64) string {
+	if bytes.Contains(header, []byte("runid=")) {
+		return "Nanopore"
+	}
+	if pacBioReadNameExp.Match(header) {
+		return "PacBio"
+	}
+	// @<instrument>:<run>:<flowcell>:<lane>:<tile>:<x>:<y>
+	if fields := bytes.Fields(header); len(fields) > 0 && bytes.Count(fields[0], []byte(":")) >= 4 {
+		return "Illumina"
+	}
+	if meanLength > longReadLength {
+		return "long-read"
+	}
+	return "unknown"
+}
+
+func (s *FileStats) addRead(length int, qual []byte) {
+	s.Reads++
+	s.ReadLength.add(length)
+	for _, q := range qual {
+		s.qualitySum += int64(q) - 33
+	}
+	s.qualityCount += int64(len(qual))
+}
+
+func scanSequenceFile(r io.Reader, stats *FileStats) ([]byte, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineLength)
+
+	var firstHeader []byte
+	lineNumber := 0
+	isFASTA := false
+	fastaLength := -1
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if lineNumber == 0 {
+			if len(line) == 0 || (line[0] != '@' && line[0] != '>') {
+				return nil, errors.New("file is not FASTA or FASTQ")
+			}
+			isFASTA = line[0] == '>'
+			firstHeader = append([]byte{}, line...)
+		}
+
+		if isFASTA {
+			if len(line) > 0 && line[0] == '>' {
+				if fastaLength >= 0 {
+					stats.addRead(fastaLength, nil)
+				}
+				fastaLength = 0
+			} else {
+				fastaLength += len(bytes.TrimSpace(line))
+			}
+		} else {
+			switch lineNumber % 4 {
+			case 1:
+				stats.ReadLength.add(len(line))
+				stats.Reads++
+			case 3:
+				for _, q := range line {
+					stats.qualitySum += int64(q) - 33
+				}
+				stats.qualityCount += int64(len(line))
+			}
+		}
+		lineNumber++
+	}
+	if isFASTA && fastaLength >= 0 {
+		stats.addRead(fastaLength, nil)
+	}
+	return firstHeader, scanner.Err()
+}
+
+func scanBAM(r io.Reader, stats *FileStats) ([]byte, error) {
+	reader, err := bam.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	var firstHeader []byte
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return firstHeader, err
+		}
+		if !record.IsPrimary() {
+			continue
+		}
+		if firstHeader == nil {
+			firstHeader = []byte("@" + record.Name)
+		}
+		// size of the record as FASTQ
+		stats.UncompressedSize += int64(len(record.Name) + 2*len(record.Seq) + 6)
+		// reads without qualities are left out of the mean quality
+		if record.MissingQual {
+			stats.addRead(len(record.Seq), nil)
+		} else {
+			stats.addRead(len(record.Seq), record.Qual)
+		}
+	}
+	return firstHeader, nil
+}
+
+// InspectFile reads a FASTA, FASTQ, or BAM file and computes its statistics
+func InspectFile(path string) (FileStats, error) {
+	stats := FileStats{Path: path}
+	info, err := os.Stat(path)
+	if err != nil {
+		return stats, err
+	}
+	stats.CompressedSize = info.Size()
+
+	f, err := os.Open(path)
+	if err != nil {
+		return stats, err
+	}
+	defer f.Close()
+
+	var header []byte
+	if IsBAM(path) {
+		header, err = scanBAM(f, &stats)
+	} else {
+		var r io.Reader = f
+		if strings.HasSuffix(path, ".gz") {
+			gz, err := gzip.NewReader(f)
+			if err != nil {
+				return stats, err
+			}
+			defer gz.Close()
+			r = gz
+		}
+		counter := &countingReader{r: r}
+		header, err = scanSequenceFile(counter, &stats)
+		stats.UncompressedSize = counter.n
+	}
+	if err != nil {
+		return stats, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	stats.ReadLength.summarize()
+	if stats.qualityCount > 0 {
+		meanQuality := float64(stats.qualitySum) / float64(stats.qualityCount)
+		stats.MeanQuality = &meanQuality
+	}
+	stats.Platform = detectPlatform(header, stats.ReadLength.Mean)
+	return stats, nil
+}
+
+func samplePairing(files SampleFiles) string {
+	if len(files.BAM) > 0 {
+		if files.BAMPaired {
+			return "paired (BAM)"
+		}
+		return "single (BAM)"
+	}
+	if len(files.R1) > 0 {
+		return "paired"
+	}
+	return "single"
+}
+
+// InspectSamples computes the statistics of every sample's input files,
+// scanning up to jobs files at a time
+func InspectSamples(samples map[string]SampleFiles, jobs int) ([]SampleStats, error) {
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	type job struct {
+		sampleName string
+		path       string
+	}
+	type result struct {
+		sampleName string
+		stats      FileStats
+		err        error
+	}
+
+	jobsChan := make(chan job)
+	results := make(chan result)
+	var wg sync.WaitGroup
+	for i := 0; i < jobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobsChan {
+				stats, err := InspectFile(j.path)
+				results <- result{sampleName: j.sampleName, stats: stats, err: err}
+			}
+		}()
+	}
+
+	go func() {
+		for sampleName, files := range samples {
+			for _, paths := range [][]string{files.R1, files.R2, files.Single, files.BAM} {
+				for _, path := range paths {
+					jobsChan <- job{sampleName: sampleName, path: path}
+				}
+			}
+		}
+		close(jobsChan)
+		wg.Wait()
+		close(results)
+	}()
+
+	fileStats := map[string][]FileStats{}
+	var firstErr error
+	for r := range results {
+		if r.err != nil && firstErr == nil {
+			firstErr = r.err
+		}
+		fileStats[r.sampleName] = append(fileStats[r.sampleName], r.stats)
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	sampleNames := make([]string, 0, len(samples))
+	for sampleName := range samples {
+		sampleNames = append(sampleNames, sampleName)
+	}
+	sort.Strings(sampleNames)
+
+	sampleStats := make([]SampleStats, 0, len(samples))
+	for _, sampleName := range sampleNames {
+		files := samples[sampleName]
+		s := SampleStats{Name: sampleName, Pairing: samplePairing(files), Files: fileStats[sampleName]}
+		sort.Slice(s.Files, func(i, j int) bool { return s.Files[i].Path < s.Files[j].Path })
+
+		s.Lanes = sortedLanes(laneSet(toLaneFiles(append(append(append([]string{}, files.R1...), files.R2...), files.Single...))))
+		qualitySum, qualityCount := int64(0), int64(0)
+		platforms := map[string]bool{}
+		for _, f := range s.Files {
+			s.CompressedSize += f.CompressedSize
+			s.UncompressedSize += f.UncompressedSize
+			s.Reads += f.Reads
+			s.ReadLength.merge(f.ReadLength)
+			qualitySum += f.qualitySum
+			qualityCount += f.qualityCount
+			platforms[f.Platform] = true
+		}
+		s.ReadLength.summarize()
+		if qualityCount > 0 {
+			meanQuality := float64(qualitySum) / float64(qualityCount)
+			s.MeanQuality = &meanQuality
+		}
+		s.Platform = "unknown"
+		if len(platforms) == 1 {
+			for p := range platforms {
+				s.Platform = p
+			}
+		} else if len(platforms) > 1 {
+			s.Platform = "mixed"
+		}
+		sampleStats = append(sampleStats, s)
+	}
+	return sampleStats, nil
+}
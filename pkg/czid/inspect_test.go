@@ -0,0 +1,113 @@
+package czid
+
+import (
+	"compress/gzip"
+	"io/fs"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestInspectFileFASTQ(t *testing.T) {
+	dirname, err := os.MkdirTemp(".", "samples")
+	defer os.RemoveAll(dirname)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	filename := path.Join(dirname, "ABC_R1.fastq.gz")
+	f, err := os.Create(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	contents := "@M00123:1:000000000-A1B2C:1:1101:1000:2000 1:N:0:1\nACGT\n+\nIIII\n" +
+		"@M00123:1:000000000-A1B2C:1:1101:1000:2001 1:N:0:1\nACGTAC\n+\n++++++\n"
+	_, err = gz.Write([]byte(contents))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz.Close()
+	f.Close()
+
+	stats, err := InspectFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.Reads != 2 {
+		t.Errorf("expected 2 reads but got %d", stats.Reads)
+	}
+	if stats.ReadLength.Min != 4 || stats.ReadLength.Max != 6 || stats.ReadLength.Mean != 5 {
+		t.Errorf("unexpected read length distribution %+v", stats.ReadLength)
+	}
+	if stats.MeanQuality == nil || *stats.MeanQuality != 22 {
+		t.Errorf("expected mean quality 22 but got %v", stats.MeanQuality)
+	}
+	if stats.Platform != "Illumina" {
+		t.Errorf("expected platform Illumina but got %s", stats.Platform)
+	}
+	if stats.UncompressedSize != int64(len(contents)) {
+		t.Errorf("expected uncompressed size %d but got %d", len(contents), stats.UncompressedSize)
+	}
+}
+
+func TestInspectSamples(t *testing.T) {
+	dirname, err := os.MkdirTemp(".", "samples")
+	defer os.RemoveAll(dirname)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{
+		"ABC_L001_R1.fastq": "@r1\nACGT\n+\nIIII\n",
+		"ABC_L001_R2.fastq": "@r1\nACGT\n+\nIIII\n",
+		"DEF.fasta":         ">c1\nACGT\nAC\n>c2\nA\n",
+	}
+	for filename, contents := range files {
+		err := os.WriteFile(path.Join(dirname, filename), []byte(contents), fs.ModePerm)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	samples, err := SamplesFromDir(dirname, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := InspectSamples(samples, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(stats) != 2 || stats[0].Name != "ABC" || stats[1].Name != "DEF" {
+		t.Fatalf("expected stats for ABC and DEF but got %+v", stats)
+	}
+	if stats[0].Pairing != "paired" || stats[0].Reads != 2 || len(stats[0].Lanes) != 1 {
+		t.Errorf("unexpected stats for ABC %+v", stats[0])
+	}
+	if stats[1].Reads != 2 || stats[1].ReadLength.Max != 6 || stats[1].ReadLength.Min != 1 {
+		t.Errorf("unexpected stats for DEF %+v", stats[1])
+	}
+}
+
+func TestDetectPlatform(t *testing.T) {
+	tests := []struct {
+		header     string
+		meanLength float64
+		expected   string
+	}{
+		{"@M00123:1:000000000-A1B2C:1:1101:1000:2000 1:N:0:1", 150, "Illumina"},
+		{"@M00123:1:000000000-A1B2C:1:1101:1000:2000 1:N:0:1", 1500, "Illumina"},
+		{"@0a1b2c3d-0000-1111-2222-333344445555 runid=abc read=10 ch=5", 5000, "Nanopore"},
+		{"@m64011_190830_220126/1/ccs", 15000, "PacBio"},
+		{"@read1", 8000, "long-read"},
+		{"@read1", 150, "unknown"},
+	}
+	for _, test := range tests {
+		if platform := detectPlatform([]byte(test.header), test.meanLength); platform != test.expected {
+			t.Errorf("expected %s for %q with mean length %.0f but got %s", test.expected, test.header, test.meanLength, platform)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package czid
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// LaneFile is a read file and the lane number parsed from its name, Lane is
+// 0 if the file name has no lane number. Err is set if the file name has a
+// lane number that can't be parsed.
+type LaneFile struct {
+	Lane int
+	Path string
+	Err  error
+}
+
+func (l LaneFile) laneString() string {
+	if l.Err != nil {
+		return "?"
+	}
+	if l.Lane == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("L%03d", l.Lane)
+}
+
+// LaneIssues are the problems found while analyzing the lanes of samples.
+// Errors prevent an upload, warnings are only reported.
+type LaneIssues struct {
+	Errors   []string
+	Warnings []string
+}
+
+func toLaneFiles(paths []string) []LaneFile {
+	laneFiles := make([]LaneFile, len(paths))
+	for i, path := range paths {
+		laneFiles[i] = LaneFile{Path: path}
+		lane, err := extractLaneNumber(path)
+		if err == nil {
+			laneFiles[i].Lane = lane
+		} else if !errors.Is(err, errNoLaneNumber) {
+			laneFiles[i].Err = err
+		}
+	}
+	sort.SliceStable(laneFiles, func(i, j int) bool {
+		if filepath.Dir(laneFiles[i].Path) != filepath.Dir(laneFiles[j].Path) {
+			return filepath.Dir(laneFiles[i].Path) < filepath.Dir(laneFiles[j].Path)
+		}
+		return laneFiles[i].Lane < laneFiles[j].Lane
+	})
+	return laneFiles
+}
+
+func formatLanes(lanes []int) string {
+	laneStrings := make([]string, len(lanes))
+	for i, lane := range lanes {
+		laneStrings[i] = fmt.Sprintf("L%03d", lane)
+	}
+	return strings.Join(laneStrings, ", ")
+}
+
+func laneSet(laneFiles []LaneFile) map[int]bool {
+	lanes := map[int]bool{}
+	for _, l := range laneFiles {
+		if l.Lane != 0 {
+			lanes[l.Lane] = true
+		}
+	}
+	return lanes
+}
+
+func sortedLanes(lanes map[int]bool) []int {
+	sorted := make([]int, 0, len(lanes))
+	for lane := range lanes {
+		sorted = append(sorted, lane)
+	}
+	sort.Ints(sorted)
+	return sorted
+}
+
+func analyzeRead(sampleName string, read string, laneFiles []LaneFile, issues *LaneIssues) {
+	for _, l := range laneFiles {
+		if l.Err != nil {
+			issues.Warnings = append(issues.Warnings, fmt.Sprintf("sample '%s' %s: %s", sampleName, read, l.Err))
+		}
+	}
+	if len(laneFiles) < 2 {
+		return
+	}
+	// the same lane from different directories is expected when merging
+	// resequencing runs, the same lane twice in one directory is not
+	type dirLane struct {
+		dir  string
+		lane int
+	}
+	seen := map[dirLane]string{}
+	for _, l := range laneFiles {
+		if l.Lane == 0 && l.Err == nil {
+			issues.Warnings = append(issues.Warnings, fmt.Sprintf(
+				"sample '%s' has multiple %s files but %s has no lane number, files will be concatenated in directory order",
+				sampleName, read, l.Path,
+			))
+		}
+		if l.Lane == 0 {
+			continue
+		}
+		key := dirLane{dir: filepath.Dir(l.Path), lane: l.Lane}
+		if other, has := seen[key]; has {
+			issues.Warnings = append(issues.Warnings, fmt.Sprintf(
+				"sample '%s' has duplicate %s files for lane L%03d: %s, %s",
+				sampleName, read, l.Lane, other, l.Path,
+			))
+		}
+		seen[key] = l.Path
+	}
+}
+
+// AnalyzeLanes checks the lane files of each sample. R1 and R2 files must
+// come in pairs from the same directory and lane. Duplicate lanes within a
+// directory, files without lane numbers or with lane numbers that can't be
+// parsed, and lanes present in other samples but missing from a sample are
+// reported as warnings.
+func AnalyzeLanes(samples map[string]SampleFiles) LaneIssues {
+	issues := LaneIssues{}
+
+	sampleNames := make([]string, 0, len(samples))
+	for sampleName := range samples {
+		sampleNames = append(sampleNames, sampleName)
+	}
+	sort.Strings(sampleNames)
+
+	allLanes := map[int]bool{}
+	for _, files := range samples {
+		for _, paths := range [][]string{files.R1, files.R2, files.Single} {
+			for lane := range laneSet(toLaneFiles(paths)) {
+				allLanes[lane] = true
+			}
+		}
+	}
+
+	for _, sampleName := range sampleNames {
+		files := samples[sampleName]
+		r1 := toLaneFiles(files.R1)
+		r2 := toLaneFiles(files.R2)
+		single := toLaneFiles(files.Single)
+
+		analyzeRead(sampleName, "R1", r1, &issues)
+		analyzeRead(sampleName, "R2", r2, &issues)
+		analyzeRead(sampleName, "single end", single, &issues)
+
+		if len(r1) > 0 || len(r2) > 0 {
+			r1Lanes := sortedLanes(laneSet(r1))
+			r2Lanes := sortedLanes(laneSet(r2))
+			if formatLanes(r1Lanes) != formatLanes(r2Lanes) {
+				issues.Errors = append(issues.Errors, fmt.Sprintf(
+					"sample '%s' has R1 files for lanes [%s] but R2 files for lanes [%s]",
+					sampleName, formatLanes(r1Lanes), formatLanes(r2Lanes),
+				))
+			} else if len(r1) == len(r2) {
+				for i := range r1 {
+					if r1[i].Lane != r2[i].Lane || filepath.Dir(r1[i].Path) != filepath.Dir(r2[i].Path) {
+						issues.Errors = append(issues.Errors, fmt.Sprintf(
+							"sample '%s' R1 file %s has no matching R2 file in the same directory and lane",
+							sampleName, r1[i].Path,
+						))
+						break
+					}
+				}
+			}
+		}
+
+		sampleLanes := laneSet(append(append(r1, r2...), single...))
+		if len(sampleLanes) == 0 {
+			continue
+		}
+		missing := []int{}
+		for _, lane := range sortedLanes(allLanes) {
+			if !sampleLanes[lane] {
+				missing = append(missing, lane)
+			}
+		}
+		if len(missing) > 0 {
+			issues.Warnings = append(issues.Warnings, fmt.Sprintf(
+				"sample '%s' is missing lanes [%s] that are present in other samples",
+				sampleName, formatLanes(missing),
+			))
+		}
+	}
+	return issues
+}
+
+// SortLaneFiles orders each sample's read files by directory then lane number
+// so R1 and R2 files are concatenated in the same order
+func SortLaneFiles(samples map[string]SampleFiles) {
+	for sampleName, files := range samples {
+		for _, paths := range [][]string{files.R1, files.R2, files.Single} {
+			for i, l := range toLaneFiles(paths) {
+				paths[i] = l.Path
+			}
+		}
+		samples[sampleName] = files
+	}
+}
+
+// PrintLaneTable writes a table of the files that will be concatenated for
+// each read of each sample
+func PrintLaneTable(w io.Writer, samples map[string]SampleFiles) error {
+	sampleNames := make([]string, 0, len(samples))
+	for sampleName := range samples {
+		sampleNames = append(sampleNames, sampleName)
+	}
+	sort.Strings(sampleNames)
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "SAMPLE\tREAD\tLANE\tFILE")
+	for _, sampleName := range sampleNames {
+		files := samples[sampleName]
+		name := sampleName
+		reads := []struct {
+			name  string
+			paths []string
+		}{{"R1", files.R1}, {"R2", files.R2}, {"single", files.Single}, {"bam", files.BAM}}
+		for _, read := range reads {
+			readName := read.name
+			for _, l := range toLaneFiles(read.paths) {
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", name, readName, l.laneString(), l.Path)
+				name = ""
+				readName = ""
+			}
+		}
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,106 @@
+package czid
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestExtractLaneNumberAboveNine(t *testing.T) {
+	n, err := extractLaneNumber("ABC_L010_R1_001.fastq.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 10 {
+		t.Errorf("expected lane 10 but got %d", n)
+	}
+
+	if name := ToSampleName("ABC_L010_R1_001.fastq.gz"); name != "ABC" {
+		t.Errorf("expected sample name 'ABC' but got '%s'", name)
+	}
+}
+
+func TestAnalyzeLanesUnparseableLane(t *testing.T) {
+	for _, filename := range []string{"ABC_L1.fastq", "ABC_L000.fastq", "ABC_L0001.fastq"} {
+		issues := AnalyzeLanes(map[string]SampleFiles{
+			"ABC": {Single: []string{filename}},
+		})
+		if len(issues.Warnings) != 1 || !strings.Contains(issues.Warnings[0], filename) {
+			t.Errorf("expected a lane number warning for %s but got %v", filename, issues.Warnings)
+		}
+	}
+
+	issues := AnalyzeLanes(map[string]SampleFiles{
+		"ABC": {Single: []string{"ABC.fastq"}},
+	})
+	if len(issues.Warnings) != 0 {
+		t.Errorf("expected no warnings for a single file without a lane number but got %v", issues.Warnings)
+	}
+}
+
+func TestAnalyzeLanesMismatchedPairs(t *testing.T) {
+	issues := AnalyzeLanes(map[string]SampleFiles{
+		"ABC": {
+			R1: []string{"ABC_L001_R1.fastq", "ABC_L002_R1.fastq"},
+			R2: []string{"ABC_L001_R2.fastq", "ABC_L003_R2.fastq"},
+		},
+	})
+
+	if len(issues.Errors) != 1 {
+		t.Fatalf("expected 1 error but got %v", issues.Errors)
+	}
+	if issues.Errors[0] != "sample 'ABC' has R1 files for lanes [L001, L002] but R2 files for lanes [L001, L003]" {
+		t.Errorf("unexpected error: %s", issues.Errors[0])
+	}
+}
+
+func TestAnalyzeLanesMissingLane(t *testing.T) {
+	issues := AnalyzeLanes(map[string]SampleFiles{
+		"ABC": {Single: []string{"ABC_L001.fastq", "ABC_L002.fastq"}},
+		"DEF": {Single: []string{"DEF_L001.fastq"}},
+	})
+
+	if len(issues.Errors) != 0 {
+		t.Fatalf("expected no errors but got %v", issues.Errors)
+	}
+	if len(issues.Warnings) != 1 || !strings.Contains(issues.Warnings[0], "'DEF' is missing lanes [L002]") {
+		t.Errorf("expected a missing lane warning for DEF but got %v", issues.Warnings)
+	}
+}
+
+func TestAnalyzeLanesDuplicateLanes(t *testing.T) {
+	issues := AnalyzeLanes(map[string]SampleFiles{
+		"ABC": {Single: []string{"run1/ABC_L001.fastq", "run2/ABC_L001.fastq"}},
+	})
+	if len(issues.Warnings) != 0 {
+		t.Errorf("expected the same lane from two runs to be merged without warnings but got %v", issues.Warnings)
+	}
+
+	issues = AnalyzeLanes(map[string]SampleFiles{
+		"ABC": {Single: []string{"run1/ABC_L001.fastq", "run1/ABC_L001.fq"}},
+	})
+	if len(issues.Warnings) != 1 || !strings.Contains(issues.Warnings[0], "duplicate single end files for lane L001") {
+		t.Errorf("expected a duplicate lane warning but got %v", issues.Warnings)
+	}
+}
+
+func TestPrintLaneTable(t *testing.T) {
+	b := bytes.NewBufferString("")
+	err := PrintLaneTable(b, map[string]SampleFiles{
+		"ABC": {
+			R1: []string{"ABC_L002_R1.fastq", "ABC_L001_R1.fastq"},
+			R2: []string{"ABC_L001_R2.fastq", "ABC_L002_R2.fastq"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("expected a header and 4 rows but got:\n%s", b.String())
+	}
+	if !strings.Contains(lines[1], "L001") || !strings.Contains(lines[2], "L002") {
+		t.Errorf("expected R1 lanes in order but got:\n%s", b.String())
+	}
+}
@@ -0,0 +1,228 @@
+package czid
+
+// This file is for finding structural problems in metadata files that reading
+// them would otherwise hide, like a later row replacing an earlier row with
+// the same sample name
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/chanzuckerberg/czid-cli/pkg/util"
+	"github.com/chanzuckerberg/czid-cli/pkg/xlsx"
+)
+
+// readRawMetadataRows reads the rows of a tabular metadata file without
+// trimming cells, delimited files are only converted to UTF-8. ok is false for
+// metadata files that aren't tabular.
+func readRawMetadataRows(path string, options MetadataReadOptions) (rows [][]string, ok bool, err error) {
+	delimiter := ','
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".xlsx":
+		rows, err := xlsx.ReadFile(path, options.Sheet)
+		return rows, true, err
+	case ".json", ".yaml", ".yml":
+		return nil, false, nil
+	case ".tsv", ".tab":
+		delimiter = '\t'
+	}
+
+	rows, err = readDelimitedRows(path, delimiter, options)
+	return rows, true, err
+}
+
+// isEmptyRow reports whether every cell of a row is empty
+func isEmptyRow(row []string) bool {
+	for _, cell := range row {
+		if trimInvisible(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// LintMetadataFile checks a metadata file for structural problems, see
+// LintMetadataRows. JSON and YAML files are only checked by reading them.
+func LintMetadataFile(path string, options MetadataReadOptions, sampleNames []string) ([]MetadataIssue, error) {
+	rows, ok, err := readRawMetadataRows(path, options)
+	if err != nil {
+		return []MetadataIssue{}, err
+	}
+	if !ok {
+		if _, err := ReadMetadataFile(path, options); err != nil {
+			return []MetadataIssue{{Severity: IssueError, Caption: err.Error()}}, nil
+		}
+		return []MetadataIssue{}, nil
+	}
+	// short rows are normal in .xlsx files, empty trailing cells are not stored
+	allowShortRows := strings.ToLower(filepath.Ext(path)) == ".xlsx"
+	return LintMetadataRows(rows, options.SampleNameColumn, allowShortRows, sampleNames), nil
+}
+
+// LintMetadataRows checks the rows of a tabular metadata file, headers first,
+// for duplicate sample names, rows with a different number of cells than the
+// headers, headers that are variants of each other, and invisible characters
+// that are trimmed when the file is read. If sampleNames is not nil, sample
+// names in the rows that match none of them are reported too. Row numbers in
+// captions count the header row as row 1.
+func LintMetadataRows(rows [][]string, sampleNameColumn string, allowShortRows bool, sampleNames []string) []MetadataIssue {
+	issues := []MetadataIssue{}
+	if len(rows) == 0 {
+		return append(issues, MetadataIssue{Severity: IssueError, Caption: "metadata file is empty"})
+	}
+	headers := rows[0]
+	for len(headers) > 0 && trimInvisible(headers[len(headers)-1]) == "" {
+		headers = headers[:len(headers)-1]
+	}
+
+	for _, header := range headers {
+		if trimmed := trimInvisible(header); trimmed != header {
+			issues = append(issues, MetadataIssue{
+				Severity: IssueWarning,
+				Caption:  fmt.Sprintf("trimmed invisible characters from column name %q", header),
+				Column:   trimmed,
+			})
+		}
+	}
+	issues = append(issues, lintHeaders(headers)...)
+
+	trimmedHeaders := make([]string, len(headers))
+	for i, header := range headers {
+		trimmedHeaders[i] = trimInvisible(header)
+	}
+	keyHeader, err := sampleNameHeader(trimmedHeaders, sampleNameColumn, false)
+	if err != nil {
+		return append(issues, MetadataIssue{Severity: IssueError, Caption: err.Error()})
+	}
+	keyIdx := 0
+	for i, header := range trimmedHeaders {
+		if header == keyHeader {
+			keyIdx = i
+			break
+		}
+	}
+
+	sampleSet := make(map[string]bool, len(sampleNames))
+	for _, sampleName := range sampleNames {
+		sampleSet[sampleName] = true
+	}
+	firstRows := map[string]int{}
+	for i, row := range rows[1:] {
+		rowNum := i + 2
+		if isEmptyRow(row) {
+			continue
+		}
+
+		width := len(row)
+		for width > len(headers) && trimInvisible(row[width-1]) == "" {
+			width--
+		}
+		if width > len(headers) {
+			issues = append(issues, MetadataIssue{
+				Severity: IssueError,
+				Caption:  fmt.Sprintf("row %d has %d cells but there are only %d columns, values without a column are ignored", rowNum, width, len(headers)),
+			})
+		} else if len(row) < len(headers) && !allowShortRows {
+			issues = append(issues, MetadataIssue{
+				Severity: IssueWarning,
+				Caption:  fmt.Sprintf("row %d has %d cells but there are %d columns, the missing cells are treated as empty", rowNum, len(row), len(headers)),
+			})
+		}
+
+		sampleName := ""
+		if keyIdx < len(row) {
+			sampleName = trimInvisible(row[keyIdx])
+		}
+		for j, cell := range row {
+			if j >= len(headers) {
+				break
+			}
+			if trimmed := trimInvisible(cell); trimmed != cell {
+				issues = append(issues, MetadataIssue{
+					Severity:   IssueWarning,
+					Caption:    fmt.Sprintf("row %d: trimmed invisible characters from %q", rowNum, cell),
+					SampleName: sampleName,
+					Column:     trimmedHeaders[j],
+					Value:      trimmed,
+				})
+			}
+		}
+
+		if sampleName == "" {
+			issues = append(issues, MetadataIssue{
+				Severity: IssueError,
+				Caption:  fmt.Sprintf("row %d has no %s", rowNum, keyHeader),
+				Column:   keyHeader,
+			})
+			continue
+		}
+		if firstRow, has := firstRows[sampleName]; has {
+			issues = append(issues, MetadataIssue{
+				Severity:   IssueError,
+				Caption:    fmt.Sprintf("%s '%s' is in rows %d and %d, the later row would replace the earlier one", keyHeader, sampleName, firstRow, rowNum),
+				SampleName: sampleName,
+				Column:     keyHeader,
+				Value:      sampleName,
+			})
+			continue
+		}
+		firstRows[sampleName] = rowNum
+		if sampleNames != nil && !sampleSet[sampleName] {
+			issues = append(issues, MetadataIssue{
+				Severity:   IssueWarning,
+				Caption:    fmt.Sprintf("row %d: sample '%s' matches no sample files, its metadata is ignored", rowNum, sampleName),
+				SampleName: sampleName,
+				Column:     keyHeader,
+				Value:      sampleName,
+			})
+		}
+	}
+	return issues
+}
+
+// lintHeaders reports headers that are the same after normalizing case,
+// underscores, and spacing as errors, and headers that are a few edits apart
+// as warnings
+func lintHeaders(headers []string) []MetadataIssue {
+	issues := []MetadataIssue{}
+	byNormalized := map[string]string{}
+	normalizedHeaders := []string{}
+	for _, header := range headers {
+		trimmed := trimInvisible(header)
+		normalized := normalizeHeader(trimmed)
+		if normalized == "" {
+			issues = append(issues, MetadataIssue{Severity: IssueWarning, Caption: "a column has no name"})
+			continue
+		}
+		if first, has := byNormalized[normalized]; has {
+			issues = append(issues, MetadataIssue{
+				Severity: IssueError,
+				Caption:  fmt.Sprintf("columns %q and %q are the same column", first, trimmed),
+				Column:   trimmed,
+			})
+			continue
+		}
+		byNormalized[normalized] = trimmed
+		normalizedHeaders = append(normalizedHeaders, normalized)
+	}
+
+	sort.Strings(normalizedHeaders)
+	for i, a := range normalizedHeaders {
+		for _, b := range normalizedHeaders[i+1:] {
+			edits := maxHeaderEdits(a)
+			if maxHeaderEdits(b) < edits {
+				edits = maxHeaderEdits(b)
+			}
+			if util.EditDistance(a, b) <= edits {
+				issues = append(issues, MetadataIssue{
+					Severity: IssueWarning,
+					Caption:  fmt.Sprintf("columns %q and %q look like the same column", byNormalized[a], byNormalized[b]),
+					Column:   byNormalized[b],
+				})
+			}
+		}
+	}
+	return issues
+}
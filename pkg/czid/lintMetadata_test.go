@@ -0,0 +1,113 @@
+package czid
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func issueCaptions(issues []MetadataIssue) []string {
+	captions := make([]string, len(issues))
+	for i, issue := range issues {
+		captions[i] = issue.Severity + ": " + issue.Caption
+	}
+	return captions
+}
+
+func TestLintMetadataRows(t *testing.T) {
+	rows := [][]string{
+		{"Sample Name", "Sample Type", "sample_type ", "Collection Dat", "Collection Date", "\u200bNotes"},
+		{"sample_1", "Serum", "", "", "2023-01-01", "ok"},
+		{"sample_2", "Serum\u00a0", "", "", "2023-01-01"},
+		{"sample_1", "CSF", "", "", "2023-01-02", "", "extra"},
+		{"", "", "", "", "", ""},
+		{"", "CSF", "", "", "", ""},
+		{"sample_9", "CSF", "", "", "", ""},
+	}
+	issues := LintMetadataRows(rows, "", false, []string{"sample_1", "sample_2"})
+	expected := []string{
+		"warning: trimmed invisible characters from column name \"\\u200bNotes\"",
+		"error: columns \"Sample Type\" and \"sample_type \" are the same column",
+		"warning: columns \"Collection Dat\" and \"Collection Date\" look like the same column",
+		"warning: row 3 has 5 cells but there are 6 columns, the missing cells are treated as empty",
+		"warning: row 3: trimmed invisible characters from \"Serum\\u00a0\"",
+		"error: row 4 has 7 cells but there are only 6 columns, values without a column are ignored",
+		"error: Sample Name 'sample_1' is in rows 2 and 4, the later row would replace the earlier one",
+		"error: row 6 has no Sample Name",
+		"warning: row 7: sample 'sample_9' matches no sample files, its metadata is ignored",
+	}
+	captions := issueCaptions(issues)
+	if strings.Join(captions, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected issues:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(captions, "\n"))
+	}
+	if issues[4].SampleName != "sample_2" || issues[4].Column != "Sample Type" || issues[4].Value != "Serum" {
+		t.Errorf("expected the trimmed cell's sample, column, and value, got %v", issues[4])
+	}
+}
+
+func TestLintMetadataRowsShortRowsAllowed(t *testing.T) {
+	rows := [][]string{
+		{"Sample Name", "Sample Type", "Notes"},
+		{"sample_1", "Serum"},
+	}
+	if issues := LintMetadataRows(rows, "", true, nil); len(issues) != 0 {
+		t.Errorf("expected no issues, got %v", issues)
+	}
+}
+
+func TestLintMetadataFile(t *testing.T) {
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, "metadata.csv")
+	if err := os.WriteFile(csvPath, []byte("Sample Name,Host Organism\nsample_1,Human\nsample_1,Human,extra\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	issues, err := LintMetadataFile(csvPath, MetadataReadOptions{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errorCount, _ := CountIssues(issues); errorCount != 2 {
+		t.Errorf("expected 2 errors, got %v", issues)
+	}
+
+	jsonPath := filepath.Join(dir, "metadata.json")
+	if err := os.WriteFile(jsonPath, []byte(`[{"Sample Name": "a"}, {"Sample Name": "a"}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	issues, err = LintMetadataFile(jsonPath, MetadataReadOptions{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 1 || issues[0].Severity != IssueError {
+		t.Errorf("expected the duplicate sample in the JSON file to be an error, got %v", issues)
+	}
+}
+
+func TestShortRowsAreReadAsEmpty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metadata.csv")
+	contents := "Sample Name,Host Organism,Sample Type\nsample_1,Human\nsample_2,Human,Blood\n"
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	issues, err := LintMetadataFile(path, MetadataReadOptions{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errorCount, warningCount := CountIssues(issues); errorCount != 0 || warningCount != 1 {
+		t.Errorf("expected a warning for the short row, got %v", issues)
+	}
+
+	// the warning says the missing cells are treated as empty, so reading
+	// the file must do that instead of failing
+	samplesMetadata, err := ReadMetadataFile(path, MetadataReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, has := samplesMetadata["sample_1"].fields["Sample Type"]; !has || value != "" {
+		t.Errorf("expected an empty Sample Type for sample_1, got %v", samplesMetadata["sample_1"])
+	}
+	if samplesMetadata["sample_2"].fields["Sample Type"] != "Blood" {
+		t.Errorf("expected Blood for sample_2, got %v", samplesMetadata["sample_2"])
+	}
+}
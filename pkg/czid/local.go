@@ -1,22 +1,45 @@
 package czid
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"regexp"
-	"sort"
 	"strconv"
 	"strings"
+
+	"github.com/chanzuckerberg/czid-cli/pkg/bam"
 )
 
 var inputExp = regexp.MustCompile(`\.(fasta|fa|fastq|fq)(\.gz)?$`)
 
 func IsInput(path string) bool {
-	return inputExp.MatchString(path)
+	return inputExp.MatchString(path) || IsBAM(path)
 }
 
-var sampleNameExp = regexp.MustCompile(`(_L00\d)?(_R[12]|_R[12]_001)?\.(fasta|fa|fastq|fq)(\.gz)?$`)
+var bamExp = regexp.MustCompile(`\.u?bam$`)
+
+// IsBAM reports whether path is a BAM or unaligned BAM file, these are
+// converted to FASTQ during upload
+func IsBAM(path string) bool {
+	return bamExp.MatchString(path)
+}
+
+// IsCRAM reports whether path is a CRAM file. CRAM files are only input when
+// the reference they were compressed against is given, they are converted to
+// FASTQ like BAM files.
+func IsCRAM(path string) bool {
+	return bam.IsCRAM(path)
+}
+
+// cramError is the error for CRAM files given as input without a reference
+func cramError(path string) error {
+	return fmt.Errorf("CRAM file %s needs the reference it was compressed against, pass it with --cram-reference or convert the file to BAM or FASTQ first", path)
+}
+
+var sampleNameExp = regexp.MustCompile(`(_L\d{3})?(_R[12]|_R[12]_001)?\.(fasta|fa|fastq|fq|bam|ubam|cram)(\.gz)?$`)
 
 func ToSampleName(path string) string {
 	return sampleNameExp.ReplaceAllString(filepath.Base(path), "")
@@ -34,15 +57,29 @@ func IsR2(path string) bool {
 	return r2Exp.MatchString(path)
 }
 
+// laneLikeExp matches file names with something like a lane number that isn't
+// the three digit lane number of sampleNameExp, ex. ABC_L1_R1.fastq
+var laneLikeExp = regexp.MustCompile(`_L\d+(_R[12]|_R[12]_001)?\.(fasta|fa|fastq|fq|bam|ubam)(\.gz)?$`)
+
+// errNoLaneNumber is returned by extractLaneNumber for paths without a lane
+// number
+var errNoLaneNumber = errors.New("path has no lane number")
+
 func extractLaneNumber(path string) (int, error) {
 	match := sampleNameExp.FindString(path)
 	if len(match) < 5 || !strings.HasPrefix(match, "_L") {
-		return 0, fmt.Errorf("path has no lane number %s", path)
+		if laneLikeExp.MatchString(path) {
+			return 0, fmt.Errorf("lane number of %s is not three digits, ex. L001", path)
+		}
+		return 0, fmt.Errorf("%w %s", errNoLaneNumber, path)
 	}
 
 	n, err := strconv.Atoi(match[2:5])
 	if err != nil {
-		return n, fmt.Errorf("path has no lane number %s", path)
+		return n, fmt.Errorf("%w %s", errNoLaneNumber, path)
+	}
+	if n == 0 {
+		return n, fmt.Errorf("lane number of %s is L000, lane numbers start at L001", path)
 	}
 	return n, nil
 }
@@ -62,9 +99,91 @@ type SampleFiles struct {
 	Single         []string
 	ReferenceFasta []string
 	PrimerBed      []string
+	// BAM files are streamed to CZ ID as gzipped FASTQ, split into R1 and R2
+	// when BAMPaired is true
+	BAM       []string
+	BAMPaired bool
+	// CRAMReference is the reference FASTA the CRAM files in BAM were
+	// compressed against
+	CRAMReference string
 }
 
-func SamplesFromDir(directory string, verbose bool) (map[string]SampleFiles, error) {
+const maxSamplesPerUpload = 500
+
+var tooManySamplesErr = fmt.Errorf("to not overwhelm CZ ID, please limit your uploads to less than %d samples per upload, and not more than 1,000 samples per week", maxSamplesPerUpload)
+
+func addSampleFile(pairs map[string]SampleFiles, path string, verbose bool, cramReference string, out io.Writer) error {
+	sampleName := ToSampleName(path)
+	sampleFiles := pairs[sampleName]
+
+	if len(pairs) >= maxSamplesPerUpload {
+		return tooManySamplesErr
+	}
+
+	if IsBAM(path) || IsCRAM(path) {
+		if len(sampleFiles.R1) != 0 || len(sampleFiles.R2) != 0 || len(sampleFiles.Single) != 0 {
+			return fmt.Errorf("found BAM file and FASTQ/FASTA files for sample '%s': %s", sampleName, path)
+		}
+
+		if verbose {
+			fmt.Fprintf(out, "detected BAM sample file for sample: %s at path %s\n", sampleName, path)
+		}
+
+		sampleFiles.BAM = append(sampleFiles.BAM, path)
+		if IsCRAM(path) {
+			sampleFiles.CRAMReference = cramReference
+		}
+	} else if IsR1(path) {
+		if len(sampleFiles.BAM) != 0 {
+			return fmt.Errorf("found BAM file and FASTQ/FASTA files for sample '%s': %s", sampleName, sampleFiles.BAM[0])
+		}
+		if len(sampleFiles.Single) != 0 {
+			return fmt.Errorf("found R1 file and single end file for sample '%s': %s, %s", sampleName, path, sampleFiles.Single)
+		}
+
+		if verbose {
+			fmt.Fprintf(out, "detected R1 sample file for sample: %s at path %s\n", sampleName, path)
+		}
+
+		sampleFiles.R1 = append(sampleFiles.R1, path)
+	} else if IsR2(path) {
+		if len(sampleFiles.BAM) != 0 {
+			return fmt.Errorf("found BAM file and FASTQ/FASTA files for sample '%s': %s", sampleName, sampleFiles.BAM[0])
+		}
+		if len(sampleFiles.Single) != 0 {
+			return fmt.Errorf("found R2 file and single end file for sample '%s': %s, %s", sampleName, path, sampleFiles.Single)
+		}
+
+		if verbose {
+			fmt.Fprintf(out, "detected R2 sample file for sample: %s at path %s\n", sampleName, path)
+		}
+
+		sampleFiles.R2 = append(sampleFiles.R2, path)
+	} else {
+		if len(sampleFiles.BAM) != 0 {
+			return fmt.Errorf("found BAM file and FASTQ/FASTA files for sample '%s': %s", sampleName, sampleFiles.BAM[0])
+		}
+		if len(sampleFiles.R1) != 0 {
+			return fmt.Errorf("found R1 file and single end file for sample '%s': %s, %s", sampleName, path, sampleFiles.R1)
+		}
+		if len(sampleFiles.R2) != 0 {
+			return fmt.Errorf("found R2 file and single end file for sample '%s': %s, %s", sampleName, path, sampleFiles.R2)
+		}
+		if len(sampleFiles.Single) != 0 {
+			return fmt.Errorf("found multiple single end files for sample '%s': %s, %s", sampleName, path, sampleFiles.Single)
+		}
+
+		if verbose {
+			fmt.Fprintf(out, "detected single sample file for sample: %s at path %s\n", sampleName, path)
+		}
+
+		sampleFiles.Single = append(sampleFiles.Single, path)
+	}
+	pairs[sampleName] = sampleFiles
+	return nil
+}
+
+func walkSampleDir(directory string, verbose bool, cramReference string, out io.Writer) (map[string]SampleFiles, error) {
 	pairs := make(map[string]SampleFiles)
 	if dir, err := os.Stat(directory); err != nil {
 		return pairs, err
@@ -73,90 +192,302 @@ func SamplesFromDir(directory string, verbose bool) (map[string]SampleFiles, err
 	}
 
 	err := filepath.Walk(directory, func(path string, f os.FileInfo, err error) error {
-		if match := IsInput(path); match {
-			sampleName := ToSampleName(path)
-			sampleFiles := pairs[sampleName]
-
-			if len(pairs) >= 500 {
-				return fmt.Errorf("to not overwhelm CZ ID, please limit your uploads to less than 500 samples per upload, and not more than 1,000 samples per week")
-			}
-
-			if IsR1(path) {
-				if len(sampleFiles.Single) != 0 {
-					return fmt.Errorf("found R1 file and single end file for sample '%s': %s, %s", sampleName, path, sampleFiles.Single)
-				}
-
-				if verbose {
-					fmt.Printf("detected R1 sample file for sample: %s at path %s\n", sampleName, path)
-				}
-
-				sampleFiles.R1 = append(sampleFiles.R1, path)
-			} else if IsR2(path) {
-				if len(sampleFiles.Single) != 0 {
-					return fmt.Errorf("found R2 file and single end file for sample '%s': %s, %s", sampleName, path, sampleFiles.Single)
-				}
-
-				if verbose {
-					fmt.Printf("detected R2 sample file for sample: %s at path %s\n", sampleName, path)
-				}
-
-				sampleFiles.R2 = append(sampleFiles.R2, path)
-			} else {
-				if len(sampleFiles.R1) != 0 {
-					return fmt.Errorf("found R1 file and single end file for sample '%s': %s, %s", sampleName, path, sampleFiles.R1)
-				}
-				if len(sampleFiles.R2) != 0 {
-					return fmt.Errorf("found R2 file and single end file for sample '%s': %s, %s", sampleName, path, sampleFiles.R2)
-				}
-				if len(sampleFiles.Single) != 0 {
-					return fmt.Errorf("found multiple single end files for sample '%s': %s, %s", sampleName, path, sampleFiles.Single)
-				}
-
-				if verbose {
-					fmt.Printf("detected single sample file for sample: %s at path %s\n", sampleName, path)
-				}
-
-				sampleFiles.Single = append(sampleFiles.Single, path)
-			}
-			pairs[sampleName] = sampleFiles
+		if err != nil {
+			return err
 		}
-		return err
+		if IsCRAM(path) && cramReference == "" {
+			fmt.Fprintf(out, "warning: skipping %s, pass the reference it was compressed against with --cram-reference to upload it\n", path)
+			return nil
+		}
+		if IsCRAM(path) {
+			return addSampleFile(pairs, path, verbose, cramReference, out)
+		}
+		if IsInput(path) {
+			return addSampleFile(pairs, path, verbose, cramReference, out)
+		}
+		return nil
 	})
+	return pairs, err
+}
+
+func validateSamples(pairs map[string]SampleFiles, verbose bool, out io.Writer) error {
+	if err := resolveBAMFiles(pairs); err != nil {
+		return err
+	}
 	for sampleName, pair := range pairs {
 		if verbose {
-			fmt.Printf("detected sample: %s\n", sampleName)
+			fmt.Fprintf(out, "detected sample: %s\n", sampleName)
 		}
 		if len(pair.R1) != len(pair.R2) {
-			return pairs, fmt.Errorf("missmatch in R1 and R2 file count for sample name '%s' %d != %d", sampleName, len(pair.R1), len(pair.R2))
+			return fmt.Errorf("missmatch in R1 and R2 file count for sample name '%s' %d != %d", sampleName, len(pair.R1), len(pair.R2))
+		}
+	}
+
+	SortLaneFiles(pairs)
+	issues := AnalyzeLanes(pairs)
+	for _, warning := range issues.Warnings {
+		fmt.Fprintf(out, "warning: %s\n", warning)
+	}
+	if len(issues.Errors) > 0 {
+		for _, e := range issues.Errors {
+			fmt.Fprintf(out, "error: %s\n", e)
+		}
+		return fmt.Errorf("found %d lane errors", len(issues.Errors))
+	}
+
+	if verbose {
+		return PrintLaneTable(out, pairs)
+	}
+	for _, pair := range pairs {
+		for _, paths := range [][]string{pair.R1, pair.R2, pair.Single} {
+			if len(paths) > 1 {
+				fmt.Fprintf(out, "concatenating lane files: %s\n", strings.Join(paths, ", "))
+			}
+		}
+	}
+	return nil
+}
+
+func SamplesFromDir(directory string, verbose bool) (map[string]SampleFiles, error) {
+	pairs, err := walkSampleDir(directory, verbose, "", os.Stdout)
+	if err != nil {
+		return pairs, err
+	}
+	return pairs, validateSamples(pairs, verbose, os.Stdout)
+}
+
+func isGlob(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// readFileList reads a newline separated list of file paths, blank lines and
+// lines starting with # are ignored
+func readFileList(listPath string) ([]string, error) {
+	b, err := os.ReadFile(listPath)
+	if err != nil {
+		return nil, err
+	}
+	paths := []string{}
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		paths = append(paths, line)
+	}
+	return paths, nil
+}
+
+// samplesFromSource discovers the sample files of a single source: a
+// directory, a glob pattern, an input file, or a file list
+func samplesFromSource(source string, verbose bool, cramReference string, out io.Writer) (map[string]SampleFiles, error) {
+	var paths []string
+	if isGlob(source) {
+		matches, err := filepath.Glob(source)
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("pattern %s did not match any files", source)
+		}
+		paths = matches
+	} else {
+		info, err := os.Stat(source)
+		if err != nil {
+			return nil, err
+		}
+		if info.IsDir() {
+			return walkSampleDir(source, verbose, cramReference, out)
+		}
+		if IsCRAM(source) && cramReference == "" {
+			return nil, cramError(source)
+		}
+		if IsInput(source) || IsCRAM(source) {
+			paths = []string{source}
+		} else {
+			paths, err = readFileList(source)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	pairs := make(map[string]SampleFiles)
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return pairs, err
+		}
+		if info.IsDir() {
+			dirPairs, err := walkSampleDir(path, verbose, cramReference, out)
+			if err != nil {
+				return pairs, err
+			}
+			if err := mergeSampleFiles(pairs, dirPairs, source); err != nil {
+				return pairs, err
+			}
+			continue
+		}
+		if IsCRAM(path) && cramReference == "" {
+			return pairs, cramError(path)
+		}
+		if !IsInput(path) && !IsCRAM(path) {
+			return pairs, fmt.Errorf("%s from %s is not a supported input file", path, source)
+		}
+		if err := addSampleFile(pairs, path, verbose, cramReference, out); err != nil {
+			return pairs, err
+		}
+	}
+	return pairs, nil
+}
+
+func isPaired(s SampleFiles) bool {
+	return len(s.R1) > 0 || len(s.R2) > 0
+}
+
+// mergeSampleFiles adds the files from src to dst, concatenating the files of
+// samples that appear in both, it returns an error if a sample is single end
+// in one and paired end in the other
+func mergeSampleFiles(dst map[string]SampleFiles, src map[string]SampleFiles, source string) error {
+	for sampleName, files := range src {
+		existing, has := dst[sampleName]
+		if !has {
+			if len(dst) >= maxSamplesPerUpload {
+				return tooManySamplesErr
+			}
+			dst[sampleName] = files
+			continue
 		}
 
-		if len(pair.R1) > 1 {
-			sort.Strings(pair.R1)
-			fmt.Printf("concatenating lane files: %s\n", strings.Join(pair.R1, ", "))
+		if (len(existing.BAM) > 0) != (len(files.BAM) > 0) {
+			return fmt.Errorf("sample '%s' is a BAM file in one input and FASTQ/FASTA files in %s", sampleName, source)
 		}
 
-		if len(pair.R2) > 1 {
-			sort.Strings(pair.R2)
-			fmt.Printf("concatenating lane files: %s\n", strings.Join(pair.R2, ", "))
+		if isPaired(existing) != isPaired(files) {
+			return fmt.Errorf(
+				"sample '%s' is single end in one input and paired end in %s: %s, %s",
+				sampleName,
+				source,
+				strings.Join(append(append(append([]string{}, existing.Single...), existing.R1...), existing.R2...), ", "),
+				strings.Join(append(append(append([]string{}, files.Single...), files.R1...), files.R2...), ", "),
+			)
 		}
 
-		if len(pair.Single) > 1 {
-			sort.Strings(pair.Single)
-			fmt.Printf("concatenating lane files: %s\n", strings.Join(pair.Single, ", "))
+		existing.R1 = appendNew(existing.R1, files.R1...)
+		existing.R2 = appendNew(existing.R2, files.R2...)
+		existing.Single = appendNew(existing.Single, files.Single...)
+		existing.BAM = appendNew(existing.BAM, files.BAM...)
+		if files.CRAMReference != "" {
+			existing.CRAMReference = files.CRAMReference
 		}
+		dst[sampleName] = existing
+	}
+	return nil
+}
 
-		if len(pair.R1) > 0 && len(pair.R2) > 0 {
-			laneNumbers := make([]int, len(pair.R1))
-			for i, path := range pair.R1 {
-				laneNumbers[i], _ = extractLaneNumber(path)
+// appendNew appends the paths that are not already present in paths, so a
+// file reachable from two inputs is only uploaded once
+func appendNew(paths []string, newPaths ...string) []string {
+	for _, newPath := range newPaths {
+		duplicate := false
+		for _, path := range paths {
+			if filepath.Clean(path) == filepath.Clean(newPath) {
+				duplicate = true
+				break
 			}
+		}
+		if !duplicate {
+			paths = append(paths, newPath)
+		}
+	}
+	return paths
+}
 
-			for i, path := range pair.R2 {
-				if n, _ := extractLaneNumber(path); laneNumbers[i] != n {
-					return pairs, fmt.Errorf("missmatched lane numbers")
-				}
+// SamplesFromPaths discovers samples from any number of directories, glob
+// patterns, input files, and newline separated file lists. Samples with the
+// same name in multiple inputs (for example resequencing runs) are merged and
+// their lane files are concatenated. CRAM files are only discovered if
+// cramReference, the reference they were compressed against, isn't empty.
+func SamplesFromPaths(sources []string, verbose bool, cramReference string) (map[string]SampleFiles, error) {
+	return DiscoverSamples(sources, verbose, cramReference, os.Stdout)
+}
+
+// DiscoverSamples is SamplesFromPaths with lane warnings and the lane table
+// written to out
+func DiscoverSamples(sources []string, verbose bool, cramReference string, out io.Writer) (map[string]SampleFiles, error) {
+	pairs := make(map[string]SampleFiles)
+	if len(sources) == 0 {
+		return pairs, errors.New("no input paths provided")
+	}
+	for _, source := range sources {
+		sourcePairs, err := samplesFromSource(source, verbose, cramReference, out)
+		if err != nil {
+			return pairs, err
+		}
+		if err := mergeSampleFiles(pairs, sourcePairs, source); err != nil {
+			return pairs, err
+		}
+	}
+	return pairs, validateSamples(pairs, verbose, out)
+}
+
+// NewSampleFiles returns the files of a sample uploaded from a single read
+// file, or from an R1 and an R2 file if r2path isn't empty. The reads of a BAM
+// file are checked for pairing the same way as when discovering samples, a
+// CRAM file needs cramReference, the reference it was compressed against.
+func NewSampleFiles(sampleName string, r1path string, r2path string, cramReference string) (SampleFiles, error) {
+	if r2path != "" {
+		if IsBAM(r1path) || IsBAM(r2path) || IsCRAM(r1path) || IsCRAM(r2path) {
+			return SampleFiles{}, errors.New("upload a BAM or CRAM file on its own, paired reads are split by their flags")
+		}
+		return SampleFiles{R1: []string{r1path}, R2: []string{r2path}}, nil
+	}
+	if IsCRAM(r1path) && cramReference == "" {
+		return SampleFiles{}, cramError(r1path)
+	}
+	if !IsBAM(r1path) && !IsCRAM(r1path) {
+		return SampleFiles{Single: []string{r1path}}, nil
+	}
+	samples := map[string]SampleFiles{sampleName: {BAM: []string{r1path}}}
+	if IsCRAM(r1path) {
+		samples[sampleName] = SampleFiles{BAM: []string{r1path}, CRAMReference: cramReference}
+	}
+	err := resolveBAMFiles(samples)
+	return samples[sampleName], err
+}
+
+// resolveBAMFiles determines whether each sample's BAM files contain paired
+// reads
+func resolveBAMFiles(samples map[string]SampleFiles) error {
+	for sampleName, files := range samples {
+		if len(files.BAM) == 0 {
+			continue
+		}
+
+		for i, path := range files.BAM {
+			paired, err := bam.IsPaired(path, files.CRAMReference)
+			if err != nil {
+				return fmt.Errorf("reading BAM file %s: %w", path, err)
 			}
+			if i > 0 && paired != files.BAMPaired {
+				return fmt.Errorf("sample '%s' has both paired and unpaired BAM files", sampleName)
+			}
+			files.BAMPaired = paired
 		}
+		samples[sampleName] = files
 	}
-	return pairs, err
+	return nil
+}
+
+// BAMFASTQNames returns the names of the FASTQ files a sample's BAM files are
+// converted to, one per read for paired reads
+func BAMFASTQNames(files SampleFiles) []string {
+	if len(files.BAM) == 0 {
+		return nil
+	}
+	base := bamExp.ReplaceAllString(strings.TrimSuffix(filepath.Base(files.BAM[0]), ".cram"), "")
+	if files.BAMPaired {
+		return []string{base + "_R1.fastq.gz", base + "_R2.fastq.gz"}
+	}
+	return []string{base + ".fastq.gz"}
 }
@@ -1,10 +1,12 @@
 package czid
 
 import (
+	"bytes"
 	"fmt"
 	"io/fs"
 	"os"
 	"path"
+	"strings"
 	"testing"
 )
 
@@ -129,3 +131,197 @@ func TestStripLaneNumber(t *testing.T) {
 		t.Errorf("'%s' != '%s'", newPath, "ABC_R1.fasta")
 	}
 }
+
+func TestSamplesFromPathsMergesRuns(t *testing.T) {
+	dirname, err := os.MkdirTemp(".", "samples")
+	defer os.RemoveAll(dirname)
+	if err != nil {
+		t.Error(err)
+	}
+
+	filenames := []string{
+		"run1/ABC_L001_R1.fastq",
+		"run1/ABC_L001_R2.fastq",
+		"run2/ABC_L001_R1.fastq",
+		"run2/ABC_L001_R2.fastq",
+		"run2/DEF.fastq",
+	}
+	for _, filename := range filenames {
+		err := os.MkdirAll(path.Dir(path.Join(dirname, filename)), fs.ModePerm)
+		if err != nil {
+			t.Error(err)
+		}
+		err = os.WriteFile(path.Join(dirname, filename), []byte{}, fs.ModePerm)
+		if err != nil {
+			t.Error(err)
+		}
+	}
+
+	fileList := path.Join(dirname, "files.txt")
+	err = os.WriteFile(fileList, []byte(path.Join(dirname, "run2/DEF.fastq")+"\n\n"), fs.ModePerm)
+	if err != nil {
+		t.Error(err)
+	}
+
+	samples, err := SamplesFromPaths([]string{
+		path.Join(dirname, "run1"),
+		path.Join(dirname, "run2", "ABC_*"),
+		fileList,
+	}, false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(samples["ABC"].R1) != 2 || len(samples["ABC"].R2) != 2 {
+		t.Fatalf("expected 2 R1 and 2 R2 files for ABC but got %v and %v", samples["ABC"].R1, samples["ABC"].R2)
+	}
+
+	if samples["ABC"].R1[1] != path.Join(dirname, "run2/ABC_L001_R1.fastq") {
+		t.Fatalf("%s != %s", samples["ABC"].R1[1], path.Join(dirname, "run2/ABC_L001_R1.fastq"))
+	}
+
+	if len(samples["DEF"].Single) != 1 {
+		t.Fatalf("expected 1 single end file for DEF but got %v", samples["DEF"].Single)
+	}
+}
+
+func TestSamplesFromPathsPairedAndSingleConflict(t *testing.T) {
+	dirname, err := os.MkdirTemp(".", "samples")
+	defer os.RemoveAll(dirname)
+	if err != nil {
+		t.Error(err)
+	}
+
+	filenames := []string{"run1/ABC_R1.fastq", "run1/ABC_R2.fastq", "run2/ABC.fastq"}
+	for _, filename := range filenames {
+		err := os.MkdirAll(path.Dir(path.Join(dirname, filename)), fs.ModePerm)
+		if err != nil {
+			t.Error(err)
+		}
+		err = os.WriteFile(path.Join(dirname, filename), []byte{}, fs.ModePerm)
+		if err != nil {
+			t.Error(err)
+		}
+	}
+
+	_, err = SamplesFromPaths([]string{path.Join(dirname, "run1"), path.Join(dirname, "run2")}, false, "")
+	if err == nil {
+		t.Fatal("expected an error for a sample that is paired end in one run and single end in another")
+	}
+}
+
+func TestBAMFASTQNames(t *testing.T) {
+	names := BAMFASTQNames(SampleFiles{BAM: []string{"dir/ABC.ubam"}, BAMPaired: true})
+	if len(names) != 2 || names[0] != "ABC_R1.fastq.gz" || names[1] != "ABC_R2.fastq.gz" {
+		t.Errorf("unexpected paired FASTQ names %v", names)
+	}
+
+	names = BAMFASTQNames(SampleFiles{BAM: []string{"dir/ABC.bam"}})
+	if len(names) != 1 || names[0] != "ABC.fastq.gz" {
+		t.Errorf("unexpected single end FASTQ names %v", names)
+	}
+
+	if name := ToSampleName("dir/ABC.ubam"); name != "ABC" {
+		t.Errorf("expected sample name 'ABC' but got '%s'", name)
+	}
+}
+
+func TestSamplesFromPathsCRAM(t *testing.T) {
+	dirname, err := os.MkdirTemp(".", "samples")
+	defer os.RemoveAll(dirname)
+	if err != nil {
+		t.Error(err)
+	}
+
+	for _, filename := range []string{"ABC.fastq", "DEF.cram"} {
+		err := os.WriteFile(path.Join(dirname, filename), []byte{}, fs.ModePerm)
+		if err != nil {
+			t.Error(err)
+		}
+	}
+
+	// CRAM files in a directory are skipped so the other samples can upload
+	var out bytes.Buffer
+	samples, err := DiscoverSamples([]string{dirname}, true, "", &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, has := samples["DEF"]; has || len(samples["ABC"].Single) != 1 {
+		t.Errorf("expected only the FASTQ sample but got %v", samples)
+	}
+	for _, message := range []string{"warning: skipping", "detected single sample file"} {
+		if !strings.Contains(out.String(), message) {
+			t.Errorf("expected %q to be written to out but got %q", message, out.String())
+		}
+	}
+
+	_, err = SamplesFromPaths([]string{path.Join(dirname, "DEF.cram")}, false, "")
+	if err == nil || !strings.Contains(err.Error(), "--cram-reference") {
+		t.Fatalf("expected an error for a CRAM file given as input without a reference but got %v", err)
+	}
+
+	if name := ToSampleName("DEF_R1.cram"); name != "DEF" {
+		t.Errorf("expected sample name 'DEF' but got '%s'", name)
+	}
+	if names := BAMFASTQNames(SampleFiles{BAM: []string{"DEF.cram"}}); len(names) != 1 || names[0] != "DEF.fastq.gz" {
+		t.Errorf("expected DEF.fastq.gz but got %v", names)
+	}
+}
+
+func TestDiscoverSamplesVerboseLaneTable(t *testing.T) {
+	dirname, err := os.MkdirTemp(".", "samples")
+	defer os.RemoveAll(dirname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, filename := range []string{"ABC_L001_R1.fastq", "ABC_L002_R1.fastq", "ABC_L001_R2.fastq", "ABC_L002_R2.fastq"} {
+		err := os.WriteFile(path.Join(dirname, filename), []byte{}, fs.ModePerm)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var out bytes.Buffer
+	if _, err := DiscoverSamples([]string{dirname}, true, "", &out); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(out.String(), "SAMPLE") != 1 {
+		t.Errorf("expected the lane table once in verbose mode but got %q", out.String())
+	}
+
+	out.Reset()
+	if _, err := DiscoverSamples([]string{dirname}, false, "", &out); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out.String(), "SAMPLE") {
+		t.Errorf("expected no lane table without verbose mode but got %q", out.String())
+	}
+	if !strings.Contains(out.String(), "concatenating lane files") {
+		t.Errorf("expected concatenated lane files to be reported but got %q", out.String())
+	}
+}
+
+func TestNewSampleFiles(t *testing.T) {
+	files, err := NewSampleFiles("ABC", "ABC.fastq", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files.Single) != 1 || len(files.BAM) != 0 {
+		t.Errorf("expected a single end sample but got %+v", files)
+	}
+
+	files, err = NewSampleFiles("ABC", "ABC_R1.fastq", "ABC_R2.fastq", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files.R1) != 1 || len(files.R2) != 1 {
+		t.Errorf("expected a paired end sample but got %+v", files)
+	}
+
+	if _, err := NewSampleFiles("ABC", "ABC.bam", "ABC_R2.fastq", ""); err == nil {
+		t.Error("expected an error for a BAM file with an R2 file")
+	}
+	if _, err := NewSampleFiles("ABC", "ABC.bam", "", ""); err == nil {
+		t.Error("expected an error for a BAM file that can't be read")
+	}
+}
@@ -0,0 +1,379 @@
+package czid
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/chanzuckerberg/czid-cli/pkg/util"
+	"gopkg.in/yaml.v2"
+)
+
+// modes for resolving collection locations
+const (
+	// LocationModeAuto uses the best match for each location
+	LocationModeAuto = "auto"
+	// LocationModeInteractive asks the user to choose between matches
+	LocationModeInteractive = "interactive"
+	// LocationModeStrict fails if a location has more than one match
+	LocationModeStrict = "strict"
+)
+
+// LocationModes are the supported location modes
+var LocationModes = []string{LocationModeAuto, LocationModeInteractive, LocationModeStrict}
+
+// LocationOptions control how raw collection locations are resolved
+type LocationOptions struct {
+	// Mode is one of LocationModes
+	Mode string
+	// Suggestions is the number of matches to consider for each location in
+	// interactive and strict mode
+	Suggestions int
+	// ChoicesPath is an optional JSON file of chosen locations. Choices in it
+	// are used without searching, and choices made interactively are saved
+	// to it.
+	ChoicesPath string
+	// LocationsPath is an optional YAML or JSON file of fully specified
+	// locations keyed by raw collection location, it takes precedence over
+	// everything else and is never modified
+	LocationsPath string
+	// NoCache ignores locations cached by previous runs, newly found
+	// locations are still cached
+	NoCache bool
+}
+
+// locationCacheName is the cache file of resolved locations
+const locationCacheName = "locations.json"
+
+// locationCache holds the resolved locations of previous runs. Locations
+// with a single match are final in every mode, the best of several matches
+// is only used in auto mode. Locations without a match are not cached so
+// they are searched again.
+type locationCache struct {
+	Unique map[string]GeoSearchSuggestion `json:"unique"`
+	Best   map[string]GeoSearchSuggestion `json:"best"`
+}
+
+func (c locationCache) get(l rawLocation, mode string) (GeoSearchSuggestion, bool) {
+	if s, has := c.Unique[locationCacheKey(l)]; has {
+		return s, true
+	}
+	if mode == LocationModeAuto || mode == "" {
+		s, has := c.Best[locationCacheKey(l)]
+		return s, has
+	}
+	return GeoSearchSuggestion{}, false
+}
+
+func locationCacheKey(l rawLocation) string {
+	if l.isHuman {
+		return "human:" + l.raw
+	}
+	return "non-human:" + l.raw
+}
+
+// LocationChoices are chosen locations keyed by raw collection location. A
+// nil location keeps the raw collection location as entered.
+type LocationChoices map[string]*GeoSearchSuggestion
+
+// ReadLocationChoices reads location choices from a JSON file, a missing file
+// has no choices
+func ReadLocationChoices(path string) (LocationChoices, error) {
+	choices := LocationChoices{}
+	contents, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return choices, nil
+	}
+	if err != nil {
+		return choices, err
+	}
+	if err := json.Unmarshal(contents, &choices); err != nil {
+		return choices, fmt.Errorf("error parsing location choices file %s: %w", path, err)
+	}
+	return choices, nil
+}
+
+// ReadLocationsFile reads fully specified locations keyed by raw collection
+// location from a YAML or JSON file. A location's name and geo level are
+// filled in from its most specific place if they are missing.
+func ReadLocationsFile(path string) (LocationChoices, error) {
+	locations := LocationChoices{}
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return locations, err
+	}
+	if err := yaml.Unmarshal(contents, &locations); err != nil {
+		return locations, fmt.Errorf("error parsing locations file %s: %w", path, err)
+	}
+	for raw, l := range locations {
+		if l == nil {
+			continue
+		}
+		if l.String() == "" {
+			return locations, fmt.Errorf("location for \"%s\" in %s needs at least a country_name", raw, path)
+		}
+		if l.Name == "" {
+			l.Name = l.String()
+		}
+		if l.GeoLevel == "" {
+			switch {
+			case l.CityName != "":
+				l.GeoLevel = "city"
+			case l.SubdivisionName != "":
+				l.GeoLevel = "subdivision"
+			case l.StateName != "":
+				l.GeoLevel = "state"
+			default:
+				l.GeoLevel = "country"
+			}
+		}
+	}
+	return locations, nil
+}
+
+// WriteLocationChoices writes location choices to a JSON file
+func WriteLocationChoices(path string, choices LocationChoices) error {
+	contents, err := json.MarshalIndent(choices, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(contents, '\n'), 0644)
+}
+
+// rawLocation is a distinct location to resolve, human locations are resolved
+// separately because they are truncated
+type rawLocation struct {
+	raw     string
+	isHuman bool
+}
+
+func describeSuggestion(s GeoSearchSuggestion) string {
+	if s.GeoLevel == "" {
+		return s.String()
+	}
+	return fmt.Sprintf("%s (%s)", s.String(), s.GeoLevel)
+}
+
+// distinctSuggestions truncates suggestions for human samples and removes the
+// suggestions that became duplicates
+func distinctSuggestions(suggestions []GeoSearchSuggestion, isHuman bool) []GeoSearchSuggestion {
+	seen := map[string]bool{}
+	distinct := []GeoSearchSuggestion{}
+	for _, s := range suggestions {
+		if isHuman {
+			s = humanizeSuggestion(s)
+		}
+		if seen[s.String()] {
+			continue
+		}
+		seen[s.String()] = true
+		distinct = append(distinct, s)
+	}
+	return distinct
+}
+
+// chooseLocation asks the user to choose one of suggestions, it returns nil
+// if the user chose to keep the raw location
+func chooseLocation(reader *bufio.Reader, out io.Writer, raw string, suggestions []GeoSearchSuggestion) (*GeoSearchSuggestion, error) {
+	fmt.Fprintf(out, "collection location \"%s\" matches several locations:\n", raw)
+	for i, s := range suggestions {
+		fmt.Fprintf(out, "  %d) %s\n", i+1, describeSuggestion(s))
+	}
+	fmt.Fprintf(out, "  0) keep \"%s\" as entered\n", raw)
+	for {
+		fmt.Fprint(out, "choose a location [1]: ")
+		input, err := reader.ReadString('\n')
+		input = strings.TrimSpace(input)
+		if err != nil && (err != io.EOF || input == "") {
+			return nil, fmt.Errorf("no location chosen for \"%s\": %w", raw, err)
+		}
+		if input == "" {
+			input = "1"
+		}
+		n, convErr := strconv.Atoi(input)
+		if convErr == nil && n >= 0 && n <= len(suggestions) {
+			if n == 0 {
+				return nil, nil
+			}
+			return &suggestions[n-1], nil
+		}
+		fmt.Fprintf(out, "please enter a number from 0 to %d\n", len(suggestions))
+		if err == io.EOF {
+			return nil, fmt.Errorf("no location chosen for \"%s\"", raw)
+		}
+	}
+}
+
+// ResolveCollectionLocations replaces each sample's raw collection location
+// with a location from CZ ID's location search. Locations from the locations
+// file, then saved choices, then the location cache are used before
+// searching, and each distinct location is only searched once. In
+// interactive mode the user is asked to choose when a location has several
+// matches, reading from in and writing to out, and in strict mode an error is
+// returned listing every ambiguous location. in should be shared with every
+// other prompt reading the same input so no prompt reads ahead into another's
+// answers.
+func (c *Client) ResolveCollectionLocations(samplesMetadata SamplesMetadata, options LocationOptions, in *bufio.Reader, out io.Writer) error {
+	choices := LocationChoices{}
+	if options.ChoicesPath != "" {
+		var err error
+		choices, err = ReadLocationChoices(options.ChoicesPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	locationsFile := LocationChoices{}
+	if options.LocationsPath != "" {
+		var err error
+		locationsFile, err = ReadLocationsFile(options.LocationsPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	cache := locationCache{}
+	if _, err := util.ReadJSONCache(locationCacheName, &cache); err != nil {
+		fmt.Fprintf(out, "warning: could not read cached locations: %s\n", err)
+	}
+	if cache.Unique == nil {
+		cache.Unique = map[string]GeoSearchSuggestion{}
+	}
+	if cache.Best == nil {
+		cache.Best = map[string]GeoSearchSuggestion{}
+	}
+	cacheChanged := false
+
+	locationSet := map[rawLocation]bool{}
+	for _, m := range samplesMetadata {
+		locationSet[rawLocation{raw: m.rawCollectionLocation, isHuman: m.isHuman()}] = true
+	}
+	locations := make([]rawLocation, 0, len(locationSet))
+	for l := range locationSet {
+		locations = append(locations, l)
+	}
+	sort.Slice(locations, func(i, j int) bool {
+		if locations[i].raw != locations[j].raw {
+			return locations[i].raw < locations[j].raw
+		}
+		return !locations[i].isHuman && locations[j].isHuman
+	})
+
+	resolved := make(map[rawLocation]GeoSearchSuggestion, len(locations))
+	ambiguous := []string{}
+	choicesChanged := false
+	for _, l := range locations {
+		if l.raw == "" {
+			resolved[l] = GeoSearchSuggestion{}
+			continue
+		}
+		choice, has := locationsFile[l.raw]
+		if !has {
+			choice, has = choices[l.raw]
+		}
+		if has {
+			if choice == nil {
+				resolved[l] = GeoSearchSuggestion{}
+			} else if l.isHuman {
+				resolved[l] = humanizeSuggestion(*choice)
+			} else {
+				resolved[l] = *choice
+			}
+			continue
+		}
+
+		if cached, has := cache.get(l, options.Mode); has && !options.NoCache {
+			resolved[l] = cached
+			continue
+		}
+
+		if options.Mode == LocationModeAuto || options.Mode == "" {
+			suggestion, err := c.GetGeoSearchSuggestion(l.raw, l.isHuman)
+			if err != nil {
+				return err
+			}
+			resolved[l] = suggestion
+			if suggestion != (GeoSearchSuggestion{}) {
+				cache.Best[locationCacheKey(l)] = suggestion
+				cacheChanged = true
+			}
+			continue
+		}
+
+		suggestions, err := c.GetGeoSearchSuggestions(l.raw, options.Suggestions)
+		if err != nil {
+			return err
+		}
+		suggestions = distinctSuggestions(suggestions, l.isHuman)
+		if len(suggestions) == 0 {
+			resolved[l] = GeoSearchSuggestion{}
+			continue
+		}
+		if len(suggestions) == 1 {
+			resolved[l] = suggestions[0]
+			cache.Unique[locationCacheKey(l)] = suggestions[0]
+			cacheChanged = true
+			continue
+		}
+
+		if options.Mode == LocationModeStrict {
+			descriptions := make([]string, len(suggestions))
+			for i, s := range suggestions {
+				descriptions[i] = describeSuggestion(s)
+			}
+			ambiguous = append(ambiguous, fmt.Sprintf("  \"%s\" matches: %s", l.raw, strings.Join(descriptions, "; ")))
+			continue
+		}
+
+		choice, err = chooseLocation(in, out, l.raw, suggestions)
+		if err != nil {
+			return err
+		}
+		if choice == nil {
+			resolved[l] = GeoSearchSuggestion{}
+		} else {
+			resolved[l] = *choice
+		}
+		choices[l.raw] = choice
+		choicesChanged = true
+	}
+
+	if len(ambiguous) > 0 {
+		return fmt.Errorf(
+			"found collection locations with several matches, choose between them with --location-mode interactive or add them to a locations file:\n%s",
+			strings.Join(ambiguous, "\n"),
+		)
+	}
+
+	for sampleName, m := range samplesMetadata {
+		m.CollectionLocation = resolved[rawLocation{raw: m.rawCollectionLocation, isHuman: m.isHuman()}]
+		samplesMetadata[sampleName] = m
+	}
+
+	for _, l := range locations {
+		n := resolved[l]
+		if l.raw != n.String() && n != (GeoSearchSuggestion{}) {
+			fmt.Fprintf(out, "  replacing location \"%s\" with \"%s\"\n", l.raw, n.String())
+		}
+	}
+
+	if cacheChanged {
+		if err := util.WriteJSONCache(locationCacheName, cache); err != nil {
+			fmt.Fprintf(out, "warning: could not cache locations: %s\n", err)
+		}
+	}
+
+	if choicesChanged && options.ChoicesPath != "" {
+		if err := WriteLocationChoices(options.ChoicesPath, choices); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "saved location choices to %s\n", options.ChoicesPath)
+	}
+	return nil
+}
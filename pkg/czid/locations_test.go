@@ -0,0 +1,238 @@
+package czid
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var springfieldResponse = []byte(`[
+  {"name": "Springfield, Illinois, USA", "geo_level": "city", "country_name": "USA", "state_name": "Illinois", "city_name": "Springfield"},
+  {"name": "Springfield, Missouri, USA", "geo_level": "city", "country_name": "USA", "state_name": "Missouri", "city_name": "Springfield"}
+]`)
+
+func newSpringfieldClient() (Client, *mockHTTPClient) {
+	httpClient := newMockHTTPClient(springfieldResponse)
+	return Client{auth0: &mockAuth0Client{}, httpClient: &httpClient}, &httpClient
+}
+
+func springfieldMetadata() SamplesMetadata {
+	return SamplesMetadata{
+		"sample one": NewMetadata(map[string]string{"Host Organism": "Mosquito", "Collection Location": "Springfield"}),
+		"sample two": NewMetadata(map[string]string{"Host Organism": "Mosquito", "Collection Location": "Springfield"}),
+	}
+}
+
+func TestResolveCollectionLocationsInteractive(t *testing.T) {
+	client, httpClient := newSpringfieldClient()
+	choicesPath := filepath.Join(t.TempDir(), "locations.json")
+	options := LocationOptions{Mode: LocationModeInteractive, Suggestions: 5, ChoicesPath: choicesPath}
+
+	samplesMetadata := springfieldMetadata()
+	var out bytes.Buffer
+	err := client.ResolveCollectionLocations(samplesMetadata, options, bufio.NewReader(strings.NewReader("7\n2\n")), &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(httpClient.calls) != 1 {
+		t.Errorf("expected one location search but found %d", len(httpClient.calls))
+	}
+	if !strings.Contains(out.String(), "please enter a number from 0 to 2") {
+		t.Errorf("expected an out of range choice to be rejected, output: %s", out.String())
+	}
+	for sampleName, m := range samplesMetadata {
+		if m.CollectionLocation.StateName != "Missouri" {
+			t.Errorf("expected %s to be located in Missouri but it was %s", sampleName, m.CollectionLocation)
+		}
+	}
+
+	// the saved choice is used without searching or asking again
+	client, httpClient = newSpringfieldClient()
+	samplesMetadata = springfieldMetadata()
+	err = client.ResolveCollectionLocations(samplesMetadata, options, bufio.NewReader(strings.NewReader("")), &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(httpClient.calls) != 0 {
+		t.Errorf("expected saved choices to be used without searching but found %d searches", len(httpClient.calls))
+	}
+	if samplesMetadata["sample one"].CollectionLocation.StateName != "Missouri" {
+		t.Errorf("expected the saved choice to be used but the location was %s", samplesMetadata["sample one"].CollectionLocation)
+	}
+}
+
+func TestResolveCollectionLocationsKeepRaw(t *testing.T) {
+	client, _ := newSpringfieldClient()
+	choicesPath := filepath.Join(t.TempDir(), "locations.json")
+	samplesMetadata := springfieldMetadata()
+	options := LocationOptions{Mode: LocationModeInteractive, Suggestions: 5, ChoicesPath: choicesPath}
+	err := client.ResolveCollectionLocations(samplesMetadata, options, bufio.NewReader(strings.NewReader("0\n")), &bytes.Buffer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if samplesMetadata["sample one"].CollectionLocation != (GeoSearchSuggestion{}) {
+		t.Errorf("expected the raw location to be kept but it was %s", samplesMetadata["sample one"].CollectionLocation)
+	}
+	choices, err := ReadLocationChoices(choicesPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if choice, has := choices["Springfield"]; !has || choice != nil {
+		t.Errorf("expected a saved choice to keep the raw location but got %v", choices)
+	}
+}
+
+func TestResolveCollectionLocationsStrict(t *testing.T) {
+	client, _ := newSpringfieldClient()
+	options := LocationOptions{Mode: LocationModeStrict, Suggestions: 5}
+	err := client.ResolveCollectionLocations(springfieldMetadata(), options, bufio.NewReader(strings.NewReader("")), &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "Springfield, Missouri, USA (city)") {
+		t.Errorf("expected an error listing the ambiguous matches but got %v", err)
+	}
+}
+
+func TestResolveCollectionLocationsHuman(t *testing.T) {
+	client, _ := newSpringfieldClient()
+	samplesMetadata := SamplesMetadata{
+		"sample one": NewMetadata(map[string]string{"Host Organism": "Human", "Collection Location": "Springfield"}),
+	}
+	options := LocationOptions{Mode: LocationModeStrict, Suggestions: 5}
+	// the choices are distinct states so they are still ambiguous once truncated
+	err := client.ResolveCollectionLocations(samplesMetadata, options, bufio.NewReader(strings.NewReader("")), &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "Missouri, USA (state)") {
+		t.Errorf("expected truncated human locations to be listed but got %v", err)
+	}
+}
+
+func TestResolveCollectionLocationsCache(t *testing.T) {
+	cacheHome := os.Getenv("XDG_CACHE_HOME")
+	defer os.Setenv("XDG_CACHE_HOME", cacheHome)
+	os.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	client, httpClient := newSpringfieldClient()
+	options := LocationOptions{Mode: LocationModeAuto}
+	samplesMetadata := springfieldMetadata()
+	samplesMetadata["human"] = NewMetadata(map[string]string{"Host Organism": "Human", "Collection Location": "Springfield"})
+	err := client.ResolveCollectionLocations(samplesMetadata, options, bufio.NewReader(strings.NewReader("")), &bytes.Buffer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(httpClient.calls) != 2 {
+		t.Errorf("expected separate searches for human and non-human samples but found %d", len(httpClient.calls))
+	}
+
+	client, httpClient = newSpringfieldClient()
+	cachedMetadata := springfieldMetadata()
+	cachedMetadata["human"] = NewMetadata(map[string]string{"Host Organism": "Human", "Collection Location": "Springfield"})
+	err = client.ResolveCollectionLocations(cachedMetadata, options, bufio.NewReader(strings.NewReader("")), &bytes.Buffer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(httpClient.calls) != 0 {
+		t.Errorf("expected cached locations to be used but found %d searches", len(httpClient.calls))
+	}
+	for sampleName, m := range cachedMetadata {
+		if m.CollectionLocation != samplesMetadata[sampleName].CollectionLocation {
+			t.Errorf("expected cached location %s for %s but got %s", samplesMetadata[sampleName].CollectionLocation, sampleName, m.CollectionLocation)
+		}
+	}
+	if cachedMetadata["human"].CollectionLocation.CityName != "" {
+		t.Errorf("expected the cached human location to be truncated but it was %s", cachedMetadata["human"].CollectionLocation)
+	}
+
+	options.NoCache = true
+	err = client.ResolveCollectionLocations(springfieldMetadata(), options, bufio.NewReader(strings.NewReader("")), &bytes.Buffer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(httpClient.calls) != 1 {
+		t.Errorf("expected the cache to be ignored but found %d searches", len(httpClient.calls))
+	}
+}
+
+func TestResolveCollectionLocationsCacheModes(t *testing.T) {
+	cacheHome := os.Getenv("XDG_CACHE_HOME")
+	defer os.Setenv("XDG_CACHE_HOME", cacheHome)
+	os.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	// a location without a match is searched again
+	httpClient := newMockHTTPClient([]byte(`[]`))
+	client := Client{auth0: &mockAuth0Client{}, httpClient: &httpClient}
+	for i := 0; i < 2; i++ {
+		err := client.ResolveCollectionLocations(springfieldMetadata(), LocationOptions{Mode: LocationModeAuto}, bufio.NewReader(strings.NewReader("")), &bytes.Buffer{})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(httpClient.calls) != 2 {
+		t.Errorf("expected a location without a match to be searched again but found %d searches", len(httpClient.calls))
+	}
+
+	// the best of several matches found in auto mode is not used in strict mode
+	client, _ = newSpringfieldClient()
+	err := client.ResolveCollectionLocations(springfieldMetadata(), LocationOptions{Mode: LocationModeAuto}, bufio.NewReader(strings.NewReader("")), &bytes.Buffer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, _ = newSpringfieldClient()
+	err = client.ResolveCollectionLocations(springfieldMetadata(), LocationOptions{Mode: LocationModeStrict, Suggestions: 5}, bufio.NewReader(strings.NewReader("")), &bytes.Buffer{})
+	if err == nil {
+		t.Error("expected the ambiguous location to still be an error in strict mode")
+	}
+
+	// a single match found in strict mode is cached
+	httpClient = newMockHTTPClient([]byte(`[{"name": "Boston, Massachusetts, USA", "geo_level": "city", "country_name": "USA", "state_name": "Massachusetts", "city_name": "Boston"}]`))
+	client = Client{auth0: &mockAuth0Client{}, httpClient: &httpClient}
+	bostonMetadata := func() SamplesMetadata {
+		return SamplesMetadata{"sample one": NewMetadata(map[string]string{"Host Organism": "Mosquito", "Collection Location": "Boston"})}
+	}
+	for _, mode := range []string{LocationModeStrict, LocationModeInteractive} {
+		samplesMetadata := bostonMetadata()
+		err := client.ResolveCollectionLocations(samplesMetadata, LocationOptions{Mode: mode, Suggestions: 5}, bufio.NewReader(strings.NewReader("")), &bytes.Buffer{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if samplesMetadata["sample one"].CollectionLocation.CityName != "Boston" {
+			t.Errorf("expected Boston but got %s", samplesMetadata["sample one"].CollectionLocation)
+		}
+	}
+	if len(httpClient.calls) != 1 {
+		t.Errorf("expected the single match to be cached but found %d searches", len(httpClient.calls))
+	}
+}
+
+func TestResolveCollectionLocationsFile(t *testing.T) {
+	locationsPath := filepath.Join(t.TempDir(), "locations.yaml")
+	err := os.WriteFile(locationsPath, []byte(`Springfield:
+  country_name: USA
+  state_name: Massachusetts
+  city_name: Springfield
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, httpClient := newSpringfieldClient()
+	samplesMetadata := springfieldMetadata()
+	options := LocationOptions{Mode: LocationModeStrict, Suggestions: 5, LocationsPath: locationsPath}
+	err = client.ResolveCollectionLocations(samplesMetadata, options, bufio.NewReader(strings.NewReader("")), &bytes.Buffer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(httpClient.calls) != 0 {
+		t.Errorf("expected the locations file to be used without searching but found %d searches", len(httpClient.calls))
+	}
+	expected := GeoSearchSuggestion{
+		Name:        "Springfield, Massachusetts, USA",
+		GeoLevel:    "city",
+		CountryName: "USA",
+		StateName:   "Massachusetts",
+		CityName:    "Springfield",
+	}
+	if samplesMetadata["sample one"].CollectionLocation != expected {
+		t.Errorf("expected location %+v but got %+v", expected, samplesMetadata["sample one"].CollectionLocation)
+	}
+}
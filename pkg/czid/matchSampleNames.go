@@ -0,0 +1,147 @@
+package czid
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/chanzuckerberg/czid-cli/pkg/util"
+)
+
+// reasons a name was matched to a sample
+const (
+	SampleNameMatchedExactly    = "exact"
+	SampleNameMatchedNormalized = "normalized"
+	SampleNameMatchedFuzzy      = "fuzzy"
+)
+
+// SampleNameMatch is a name from metadata matched to a sample's name
+type SampleNameMatch struct {
+	Name   string `json:"name"`
+	Sample string `json:"sample"`
+	Reason string `json:"reason"`
+}
+
+func (m SampleNameMatch) String() string {
+	return fmt.Sprintf("matched '%s' to sample '%s' (%s)", m.Name, m.Sample, m.Reason)
+}
+
+// sampleNameKey lower cases a sample name, removes everything but letters and
+// digits, and removes leading zeros from numbers so S-01, s_1, and S1 have
+// the same key
+func sampleNameKey(name string) string {
+	var b strings.Builder
+	inNumber := false
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case unicode.IsDigit(r):
+			if r == '0' && !inNumber {
+				continue
+			}
+			inNumber = true
+			b.WriteRune(r)
+		case unicode.IsLetter(r):
+			inNumber = false
+			b.WriteRune(r)
+		default:
+			inNumber = false
+		}
+	}
+	return b.String()
+}
+
+// sampleNameDigits returns the digits of a sample name key, fuzzy matches
+// must have the same numbers so sample_1 is never matched to sample_2
+func sampleNameDigits(key string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, key)
+}
+
+// MatchSampleNames matches names from metadata to sample names. Names are
+// matched exactly, then by sampleNameKey, then by keys with the same numbers
+// and a few misspelled letters. Each sample is matched to at most one name
+// and ambiguous matches are left unmatched. Matches are sorted by name and
+// the unmatched names are returned sorted.
+func MatchSampleNames(names []string, sampleNames []string) ([]SampleNameMatch, []string) {
+	sortedNames := append([]string{}, names...)
+	sort.Strings(sortedNames)
+
+	used := map[string]bool{}
+	matched := map[string]SampleNameMatch{}
+	samples := map[string]bool{}
+	for _, sampleName := range sampleNames {
+		samples[sampleName] = true
+	}
+	for _, name := range sortedNames {
+		if samples[name] {
+			matched[name] = SampleNameMatch{Name: name, Sample: name, Reason: SampleNameMatchedExactly}
+			used[name] = true
+		}
+	}
+
+	// candidates returns the unused samples accepted by match
+	candidates := func(match func(sampleKey string) bool) []string {
+		found := []string{}
+		for _, sampleName := range sampleNames {
+			if !used[sampleName] && match(sampleNameKey(sampleName)) {
+				found = append(found, sampleName)
+			}
+		}
+		return found
+	}
+
+	nameKeys := map[string]int{}
+	for _, name := range sortedNames {
+		if _, has := matched[name]; !has {
+			nameKeys[sampleNameKey(name)]++
+		}
+	}
+	for _, name := range sortedNames {
+		key := sampleNameKey(name)
+		if _, has := matched[name]; has || key == "" || nameKeys[key] > 1 {
+			continue
+		}
+		found := candidates(func(sampleKey string) bool { return sampleKey == key })
+		if len(found) == 1 {
+			matched[name] = SampleNameMatch{Name: name, Sample: found[0], Reason: SampleNameMatchedNormalized}
+			used[found[0]] = true
+		}
+	}
+
+	for _, name := range sortedNames {
+		key := sampleNameKey(name)
+		if _, has := matched[name]; has || key == "" || nameKeys[key] > 1 {
+			continue
+		}
+		bestDistance := maxHeaderEdits(key) + 1
+		best := []string{}
+		for _, sampleName := range candidates(func(sampleKey string) bool { return sampleNameDigits(sampleKey) == sampleNameDigits(key) }) {
+			distance := util.EditDistance(key, sampleNameKey(sampleName))
+			if distance < bestDistance {
+				bestDistance, best = distance, []string{sampleName}
+			} else if distance == bestDistance {
+				best = append(best, sampleName)
+			}
+		}
+		if len(best) == 1 {
+			matched[name] = SampleNameMatch{Name: name, Sample: best[0], Reason: SampleNameMatchedFuzzy}
+			used[best[0]] = true
+		}
+	}
+
+	matches := make([]SampleNameMatch, 0, len(matched))
+	unmatched := []string{}
+	for _, name := range sortedNames {
+		if match, has := matched[name]; has {
+			matches = append(matches, match)
+		} else {
+			unmatched = append(unmatched, name)
+		}
+	}
+	return matches, unmatched
+}
@@ -0,0 +1,50 @@
+package czid
+
+import (
+	"testing"
+)
+
+func TestSampleNameKey(t *testing.T) {
+	for name, key := range map[string]string{
+		"S-01":       "s1",
+		"s_1":        "s1",
+		"S1":         "s1",
+		"S-10":       "s10",
+		"Sample 007": "sample7",
+		"---":        "",
+	} {
+		if got := sampleNameKey(name); got != key {
+			t.Errorf("expected key of %s to be %s, got %s", name, key, got)
+		}
+	}
+}
+
+func TestMatchSampleNames(t *testing.T) {
+	names := []string{"sample_one", "S-01", "Sampel_2", "sample_3", "unknown", "S-05", "s_05"}
+	sampleNames := []string{"sample_one", "S01", "sample_2", "sample_4", "S5"}
+
+	matches, unmatched := MatchSampleNames(names, sampleNames)
+	expected := []SampleNameMatch{
+		{Name: "S-01", Sample: "S01", Reason: SampleNameMatchedNormalized},
+		{Name: "Sampel_2", Sample: "sample_2", Reason: SampleNameMatchedFuzzy},
+		{Name: "sample_one", Sample: "sample_one", Reason: SampleNameMatchedExactly},
+	}
+	if len(matches) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, matches)
+	}
+	for i, match := range matches {
+		if match != expected[i] {
+			t.Errorf("expected %v, got %v", expected[i], match)
+		}
+	}
+	// sample_3 must not match sample_4, and S-05 and s_05 are ambiguous
+	expectedUnmatched := []string{"S-05", "s_05", "sample_3", "unknown"}
+	if len(unmatched) != len(expectedUnmatched) {
+		t.Fatalf("expected unmatched %v, got %v", expectedUnmatched, unmatched)
+	}
+	for i, name := range unmatched {
+		if name != expectedUnmatched[i] {
+			t.Errorf("expected unmatched %v, got %v", expectedUnmatched, unmatched)
+		}
+	}
+}
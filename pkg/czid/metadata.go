@@ -1,14 +1,17 @@
 package czid
 
 import (
-	"encoding/csv"
+	"bufio"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 	"strings"
 	"unicode"
+
+	"github.com/chanzuckerberg/czid-cli/pkg/xlsx"
 )
 
 type Metadata struct {
@@ -97,17 +100,54 @@ func trimInvisible(s string) string {
 type SamplesMetadata = map[string]Metadata
 
 func CSVMetadata(csvpath string) (SamplesMetadata, error) {
-	samplesMetadata := SamplesMetadata{}
-	f, err := os.Open(csvpath)
+	return delimitedMetadata(csvpath, ',', MetadataReadOptions{})
+}
+
+// delimitedMetadata reads metadata from a delimited file in any supported
+// encoding, defaultDelimiter is used if options doesn't set the delimiter and
+// it can't be detected
+func delimitedMetadata(path string, defaultDelimiter rune, options MetadataReadOptions) (SamplesMetadata, error) {
+	rows, err := readDelimitedRows(path, defaultDelimiter, options)
 	if err != nil {
-		return samplesMetadata, err
+		return SamplesMetadata{}, err
 	}
-	defer f.Close()
-	reader := csv.NewReader(f)
-	rows, err := reader.ReadAll()
-	if err != nil {
-		return samplesMetadata, err
+	return rowsMetadata(rows, options)
+}
+
+// sampleNameHeader returns the header samples are keyed by, sampleNameColumn
+// or the "Sample Name" column if it is empty. If keyBySampleID is true
+// samples are keyed by sample ID if there is no sample name, this is only
+// useful for updating the metadata of uploaded samples.
+func sampleNameHeader(headers []string, sampleNameColumn string, keyBySampleID bool) (string, error) {
+	keyHeader := ""
+	for _, header := range headers {
+		if sampleNameColumn != "" {
+			if normalizeHeader(header) == normalizeHeader(sampleNameColumn) {
+				return header, nil
+			}
+			continue
+		}
+		if sampleNameAliases[header] {
+			return header, nil
+		}
+		if header == "Sample ID" && keyBySampleID {
+			keyHeader = header
+		}
 	}
+	if keyHeader == "" && sampleNameColumn != "" {
+		return "", fmt.Errorf("sample name column '%s' was not found", sampleNameColumn)
+	}
+	if keyHeader == "" {
+		return "", errors.New("column 'Sample Name' is required but it was not found")
+	}
+	return keyHeader, nil
+}
+
+// rowsMetadata parses metadata from rows of cells where the first row is the
+// headers, it is shared by all tabular metadata file formats. Samples are
+// keyed by the column sampleNameHeader chooses for options.
+func rowsMetadata(rows [][]string, options MetadataReadOptions) (SamplesMetadata, error) {
+	samplesMetadata := SamplesMetadata{}
 	if len(rows) < 2 {
 		return samplesMetadata, nil
 	}
@@ -116,15 +156,9 @@ func CSVMetadata(csvpath string) (SamplesMetadata, error) {
 		headers[i] = trimInvisible(header)
 	}
 
-	hasSampleName := false
-	for _, header := range headers {
-		if header == "Sample Name" {
-			hasSampleName = true
-			break
-		}
-	}
-	if !hasSampleName {
-		return samplesMetadata, errors.New("column 'Sample Name' is required but it was not found")
+	keyHeader, err := sampleNameHeader(headers, options.SampleNameColumn, options.KeyBySampleID)
+	if err != nil {
+		return samplesMetadata, err
 	}
 	for rowNum, row := range rows[1:] {
 		if len(row) == 0 || (len(row) == 1 && trimInvisible(row[0]) == "") {
@@ -134,11 +168,16 @@ func CSVMetadata(csvpath string) (SamplesMetadata, error) {
 		sampleName := ""
 		metadata := make(map[string]string, len(headers))
 		for i, header := range headers {
-			if trimInvisible(header) == "Sample Name" {
+			if trimInvisible(header) == keyHeader {
 				if i >= len(row) {
-					return samplesMetadata, fmt.Errorf("row %d is missing 'Sample Name'", rowNum)
+					return samplesMetadata, fmt.Errorf("row %d is missing '%s'", rowNum, keyHeader)
 				}
 				sampleName = trimInvisible(row[i])
+				// rows keyed by sample ID are matched to samples by their
+				// "Sample ID", not by their key
+				if keyHeader == "Sample ID" {
+					metadata[header] = sampleName
+				}
 			} else {
 				if i >= len(row) {
 					metadata[header] = ""
@@ -152,18 +191,78 @@ func CSVMetadata(csvpath string) (SamplesMetadata, error) {
 	return samplesMetadata, nil
 }
 
+// MetadataReadOptions are options for reading metadata files
+type MetadataReadOptions struct {
+	// Sheet selects the sheet of an .xlsx file by name or 1 based index,
+	// the first sheet is used if it is empty
+	Sheet string
+	// SampleNameColumn is the column samples are keyed by, "Sample Name" is
+	// used if it is empty
+	SampleNameColumn string
+	// KeyBySampleID keys samples without a sample name column by their
+	// "Sample ID" column, only metadata of uploaded samples can be matched by
+	// sample ID
+	KeyBySampleID bool
+	// Encoding is one of MetadataEncodings, the encoding of delimited files is
+	// detected if it is empty
+	Encoding string
+	// Delimiter is one of MetadataDelimiters, the delimiter of delimited
+	// files is detected if it is empty
+	Delimiter string
+	// Rules fill in metadata missing from the metadata file and flags, they
+	// are only applied when combining metadata with sample files
+	Rules MetadataRules
+	// SampleNameMatches key the metadata of rows by the samples they were
+	// matched to, they are only applied when combining metadata with sample
+	// files
+	SampleNameMatches []SampleNameMatch
+}
+
+// ReadMetadataFile reads metadata from a .csv, .tsv, .xlsx, .json, or .yaml
+// file based on its extension, files with other extensions are read as CSV
+func ReadMetadataFile(path string, options MetadataReadOptions) (SamplesMetadata, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".xlsx":
+		rows, err := xlsx.ReadFile(path, options.Sheet)
+		if err != nil {
+			return SamplesMetadata{}, err
+		}
+		return rowsMetadata(rows, options)
+	case ".tsv", ".tab":
+		return delimitedMetadata(path, '\t', options)
+	case ".json":
+		return jsonMetadata(path, options)
+	case ".yaml", ".yml":
+		return yamlMetadata(path, options)
+	default:
+		return delimitedMetadata(path, ',', options)
+	}
+}
+
 // GetCombinedMetadata parses the metadata CSV, validates it, then fuses it with flag-based metadata
 func GetCombinedMetadata(sampleFiles map[string]SampleFiles, stringMetadata map[string]string, metadataCSVPath string) (SamplesMetadata, error) {
+	return GetCombinedMetadataWithOptions(sampleFiles, stringMetadata, metadataCSVPath, MetadataReadOptions{})
+}
+
+// GetCombinedMetadataWithOptions is GetCombinedMetadata for any supported
+// metadata file format, metadata still missing is filled in by the rules
+func GetCombinedMetadataWithOptions(
+	sampleFiles map[string]SampleFiles,
+	stringMetadata map[string]string,
+	metadataCSVPath string,
+	readOptions MetadataReadOptions,
+) (SamplesMetadata, error) {
 	metadata := NewMetadata(stringMetadata)
 	hasMetadataCSV := metadataCSVPath != ""
 
 	samplesMetadata := SamplesMetadata{}
 	if hasMetadataCSV {
 		var err error
-		samplesMetadata, err = CSVMetadata(metadataCSVPath)
+		samplesMetadata, err = ReadMetadataFile(metadataCSVPath, readOptions)
 		if err != nil {
 			return samplesMetadata, err
 		}
+		ApplySampleNameMatches(samplesMetadata, readOptions.SampleNameMatches)
 
 		for sampleName := range samplesMetadata {
 			if _, hasSampleName := sampleFiles[sampleName]; !hasSampleName {
@@ -190,34 +289,10 @@ func GetCombinedMetadata(sampleFiles map[string]SampleFiles, stringMetadata map[
 		samplesMetadata[sampleName] = m.Fuse(metadata)
 	}
 
-	return samplesMetadata, nil
+	err := ApplyMetadataRules(samplesMetadata, sampleFiles, readOptions.Rules)
+	return samplesMetadata, err
 }
 
 func GeoSearchSuggestions(samplesMetadata *SamplesMetadata) error {
-	remapping := make(map[string]GeoSearchSuggestion, len(*samplesMetadata))
-	for sampleName, metadata := range *samplesMetadata {
-		if c, has := remapping[metadata.rawCollectionLocation]; has {
-			metadata.CollectionLocation = c
-			(*samplesMetadata)[sampleName] = metadata
-			continue
-		}
-		suggestion, err := DefaultClient.GetGeoSearchSuggestion(
-			metadata.rawCollectionLocation,
-			metadata.isHuman(),
-		)
-		if err != nil {
-			return err
-		}
-		metadata.CollectionLocation = suggestion
-		(*samplesMetadata)[sampleName] = metadata
-		remapping[metadata.rawCollectionLocation] = suggestion
-	}
-
-	for o, n := range remapping {
-		if o != n.String() && n != (GeoSearchSuggestion{}) {
-			fmt.Printf("  replacing location \"%s\" with \"%s\"\n", o, n.String())
-		}
-	}
-
-	return nil
+	return DefaultClient.ResolveCollectionLocations(*samplesMetadata, LocationOptions{Mode: LocationModeAuto}, bufio.NewReader(os.Stdin), os.Stdout)
 }
@@ -0,0 +1,234 @@
+package czid
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// kinds of metadata changes
+const (
+	MetadataAdded   = "added"
+	MetadataChanged = "changed"
+	MetadataRemoved = "removed"
+)
+
+// MetadataChange is a difference between a sample's metadata in CZ ID and
+// in a metadata file
+type MetadataChange struct {
+	Kind       string `json:"kind"`
+	SampleName string `json:"sample_name"`
+	Column     string `json:"column"`
+	Old        string `json:"old"`
+	New        string `json:"new"`
+}
+
+// values returns a sample's metadata as strings keyed by column, with the
+// host organism and collection location under their CZ ID field names
+func (m Metadata) values() map[string]string {
+	values := make(map[string]string, len(m.fields)+2)
+	for k, v := range m.fields {
+		if isHostGenomeHeader(k) {
+			continue
+		}
+		values[k] = v
+	}
+	if m.HostGenome != "" {
+		values["Host Organism"] = m.HostGenome
+	}
+	if m.CollectionLocation != (GeoSearchSuggestion{}) {
+		if m.CollectionLocation.Name != "" {
+			values["Collection Location"] = m.CollectionLocation.Name
+		} else {
+			values["Collection Location"] = m.CollectionLocation.String()
+		}
+	} else if m.rawCollectionLocation != "" {
+		values["Collection Location"] = m.rawCollectionLocation
+	}
+	return values
+}
+
+// values returns a project sample's metadata including its host organism
+func (s ProjectSample) values() map[string]string {
+	values := make(map[string]string, len(s.Metadata)+1)
+	for k, v := range s.Metadata {
+		values[k] = v
+	}
+	if s.HostGenome != "" {
+		values["Host Organism"] = s.HostGenome
+	}
+	return values
+}
+
+// MatchProjectSamples re-keys samples' metadata by the names of the project
+// samples they belong to. Metadata is matched by sample name, then by a
+// "Sample ID" column. Sample names are never matched as sample IDs, a sample
+// named 1001 is not the sample with ID 1001. Samples without a host organism
+// get the project sample's host organism. The names of metadata samples that
+// matched no project sample are returned.
+func MatchProjectSamples(samplesMetadata SamplesMetadata, projectSamples []ProjectSample) (SamplesMetadata, []string) {
+	byName := make(map[string]ProjectSample, len(projectSamples))
+	byID := make(map[int]ProjectSample, len(projectSamples))
+	for _, s := range projectSamples {
+		byName[s.Name] = s
+		byID[s.ID] = s
+	}
+
+	matched := make(SamplesMetadata, len(samplesMetadata))
+	unmatched := []string{}
+	for sampleName, m := range samplesMetadata {
+		sampleID := m.fields["Sample ID"]
+		delete(m.fields, "Sample ID")
+
+		s, has := byName[sampleName]
+		if !has {
+			if id, err := strconv.Atoi(sampleID); err == nil {
+				s, has = byID[id]
+			}
+		}
+		if !has {
+			unmatched = append(unmatched, sampleName)
+			continue
+		}
+		if m.HostGenome == "" {
+			m.HostGenome = s.HostGenome
+		}
+		matched[s.Name] = m
+	}
+	sort.Strings(unmatched)
+	return matched, unmatched
+}
+
+// DiffMetadata compares samples' metadata against the metadata of project
+// samples. Only columns present in samplesMetadata are compared unless
+// includeMissing is true, in which case values in CZ ID missing from
+// samplesMetadata are reported as removed. An empty value for a column that
+// has a value in CZ ID is reported as removed.
+func DiffMetadata(projectSamples []ProjectSample, samplesMetadata SamplesMetadata, includeMissing bool) []MetadataChange {
+	current := make(map[string]map[string]string, len(projectSamples))
+	for _, s := range projectSamples {
+		current[s.Name] = s.values()
+	}
+
+	sampleNames := make([]string, 0, len(samplesMetadata))
+	for sampleName := range samplesMetadata {
+		sampleNames = append(sampleNames, sampleName)
+	}
+	sort.Strings(sampleNames)
+
+	changes := []MetadataChange{}
+	for _, sampleName := range sampleNames {
+		oldValues := map[string]string{}
+		oldColumns := map[string]string{}
+		for column, value := range current[sampleName] {
+			oldValues[normalizeHeader(column)] = value
+			oldColumns[normalizeHeader(column)] = column
+		}
+
+		newValues := samplesMetadata[sampleName].values()
+		columns := make([]string, 0, len(newValues))
+		for column := range newValues {
+			columns = append(columns, column)
+		}
+		sort.Strings(columns)
+
+		seen := map[string]bool{}
+		for _, column := range columns {
+			normalized := normalizeHeader(column)
+			seen[normalized] = true
+			oldValue, newValue := oldValues[normalized], newValues[column]
+			switch {
+			case oldValue == newValue:
+				continue
+			case oldValue == "":
+				changes = append(changes, MetadataChange{Kind: MetadataAdded, SampleName: sampleName, Column: column, New: newValue})
+			case newValue == "":
+				changes = append(changes, MetadataChange{Kind: MetadataRemoved, SampleName: sampleName, Column: column, Old: oldValue})
+			default:
+				changes = append(changes, MetadataChange{Kind: MetadataChanged, SampleName: sampleName, Column: column, Old: oldValue, New: newValue})
+			}
+		}
+
+		if !includeMissing {
+			continue
+		}
+		missing := []string{}
+		for normalized := range oldValues {
+			if !seen[normalized] {
+				missing = append(missing, normalized)
+			}
+		}
+		sort.Strings(missing)
+		for _, normalized := range missing {
+			changes = append(changes, MetadataChange{Kind: MetadataRemoved, SampleName: sampleName, Column: oldColumns[normalized], Old: oldValues[normalized]})
+		}
+	}
+	return changes
+}
+
+// PrintMetadataChanges prints metadata changes grouped by sample
+func PrintMetadataChanges(w io.Writer, changes []MetadataChange) {
+	sampleName := ""
+	for _, c := range changes {
+		if c.SampleName != sampleName {
+			sampleName = c.SampleName
+			fmt.Fprintf(w, "%s:\n", sampleName)
+		}
+		switch c.Kind {
+		case MetadataAdded:
+			fmt.Fprintf(w, "  + %s: %s\n", c.Column, c.New)
+		case MetadataRemoved:
+			fmt.Fprintf(w, "  - %s: %s\n", c.Column, c.Old)
+		default:
+			fmt.Fprintf(w, "  ~ %s: %s -> %s\n", c.Column, c.Old, c.New)
+		}
+	}
+}
+
+// FillMissingMetadata fills in columns that some samples are missing with
+// their values in CZ ID. Metadata is sent to CZ ID as a table so every sample
+// has every column, this keeps a column one sample is updating from clearing
+// the values of the samples that aren't updating it. Collection locations
+// are filled in with the location CZ ID stores so they are resent as they
+// are instead of being replaced with their name.
+func FillMissingMetadata(samplesMetadata SamplesMetadata, projectSamples []ProjectSample) {
+	current := make(map[string]map[string]string, len(projectSamples))
+	locations := make(map[string]GeoSearchSuggestion, len(projectSamples))
+	for _, s := range projectSamples {
+		locations[s.Name] = s.CollectionLocation
+		current[s.Name] = map[string]string{}
+		for column, value := range s.Metadata {
+			current[s.Name][normalizeHeader(column)] = value
+		}
+	}
+
+	headers := map[string]bool{}
+	hasLocation := false
+	for _, m := range samplesMetadata {
+		for header := range m.fields {
+			headers[header] = true
+		}
+		if m.rawCollectionLocation != "" || m.CollectionLocation != (GeoSearchSuggestion{}) {
+			hasLocation = true
+		}
+	}
+
+	for sampleName, m := range samplesMetadata {
+		for header := range headers {
+			if _, has := m.fields[header]; has {
+				continue
+			}
+			m.fields[header] = current[sampleName][normalizeHeader(header)]
+		}
+		if hasLocation && m.rawCollectionLocation == "" && m.CollectionLocation == (GeoSearchSuggestion{}) {
+			if location := locations[sampleName]; location != (GeoSearchSuggestion{}) {
+				m.CollectionLocation = location
+			} else {
+				// locations entered before locations were searched are text
+				m.rawCollectionLocation = current[sampleName]["collection location"]
+			}
+			samplesMetadata[sampleName] = m
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package czid
+
+// This file is for converting metadata files saved with other encodings to
+// UTF-8 and detecting the delimiter of delimited metadata files, spreadsheet
+// programs save them differently depending on the locale
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// metadata file encodings
+const (
+	MetadataEncodingAuto        = "auto"
+	MetadataEncodingUTF8        = "utf-8"
+	MetadataEncodingUTF16       = "utf-16"
+	MetadataEncodingUTF16LE     = "utf-16le"
+	MetadataEncodingUTF16BE     = "utf-16be"
+	MetadataEncodingLatin1      = "latin-1"
+	MetadataEncodingWindows1252 = "windows-1252"
+)
+
+// MetadataEncodings are the supported metadata file encodings
+var MetadataEncodings = []string{
+	MetadataEncodingAuto,
+	MetadataEncodingUTF8,
+	MetadataEncodingUTF16,
+	MetadataEncodingUTF16LE,
+	MetadataEncodingUTF16BE,
+	MetadataEncodingLatin1,
+	MetadataEncodingWindows1252,
+}
+
+// MetadataDelimiters are the supported delimiters of delimited metadata
+// files, "tab" is a tab character
+var MetadataDelimiters = []string{"auto", ",", ";", "tab", "|"}
+
+// detectedDelimiters are the delimiters considered when detecting the
+// delimiter of a file
+var detectedDelimiters = []rune{',', ';', '\t', '|'}
+
+// windows1252 maps the bytes 0x80 to 0x9F of Windows-1252 to runes, other
+// bytes are the same as Latin-1. Unused bytes map to the replacement rune.
+var windows1252 = [32]rune{
+	'€', '�', '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', '�', 'Ž', '�',
+	'�', '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', '�', 'ž', 'Ÿ',
+}
+
+var (
+	utf8BOM    = []byte{0xEF, 0xBB, 0xBF}
+	utf16LEBOM = []byte{0xFF, 0xFE}
+	utf16BEBOM = []byte{0xFE, 0xFF}
+)
+
+func decodeUTF16(data []byte, bigEndian bool) string {
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		if bigEndian {
+			units[i] = uint16(data[2*i])<<8 | uint16(data[2*i+1])
+		} else {
+			units[i] = uint16(data[2*i+1])<<8 | uint16(data[2*i])
+		}
+	}
+	return string(utf16.Decode(units))
+}
+
+func decodeSingleByte(data []byte, table *[32]rune) string {
+	var b strings.Builder
+	b.Grow(len(data))
+	for _, c := range data {
+		if table != nil && c >= 0x80 && c < 0xA0 {
+			b.WriteRune(table[c-0x80])
+		} else {
+			b.WriteRune(rune(c))
+		}
+	}
+	return b.String()
+}
+
+// guessUTF16 guesses whether text without a byte order mark is UTF-16 from
+// the zero bytes of ASCII characters, which are every other byte
+func guessUTF16(data []byte) (isUTF16 bool, bigEndian bool) {
+	sample := data
+	if len(sample) > 512 {
+		sample = sample[:512]
+	}
+	if len(sample) < 4 {
+		return false, false
+	}
+	evenZeros, oddZeros := 0, 0
+	for i := 0; i+1 < len(sample); i += 2 {
+		if sample[i] == 0 {
+			evenZeros++
+		}
+		if sample[i+1] == 0 {
+			oddZeros++
+		}
+	}
+	pairs := len(sample) / 2
+	switch {
+	case oddZeros*2 > pairs && evenZeros*10 < pairs:
+		return true, false
+	case evenZeros*2 > pairs && oddZeros*10 < pairs:
+		return true, true
+	default:
+		return false, false
+	}
+}
+
+// decodeMetadataText converts the contents of a metadata file to UTF-8 and
+// removes any byte order mark. With the auto encoding, byte order marks and
+// UTF-16 are detected, and files that aren't valid UTF-8 are read as
+// Windows-1252, which is what spreadsheet programs on Windows save.
+func decodeMetadataText(data []byte, encoding string) (string, error) {
+	switch strings.ToLower(encoding) {
+	case "", MetadataEncodingAuto:
+		switch {
+		case bytes.HasPrefix(data, utf8BOM):
+			return decodeMetadataText(data, MetadataEncodingUTF8)
+		case bytes.HasPrefix(data, utf16LEBOM), bytes.HasPrefix(data, utf16BEBOM):
+			return decodeMetadataText(data, MetadataEncodingUTF16)
+		}
+		if isUTF16, bigEndian := guessUTF16(data); isUTF16 {
+			return decodeUTF16(data, bigEndian), nil
+		}
+		if utf8.Valid(data) {
+			return string(data), nil
+		}
+		return decodeSingleByte(data, &windows1252), nil
+	case MetadataEncodingUTF8, "utf8":
+		data = bytes.TrimPrefix(data, utf8BOM)
+		if !utf8.Valid(data) {
+			return "", errors.New("metadata file is not valid UTF-8, set its encoding with --metadata-encoding")
+		}
+		return string(data), nil
+	case MetadataEncodingUTF16, "utf16":
+		if bytes.HasPrefix(data, utf16BEBOM) {
+			return decodeUTF16(data[2:], true), nil
+		}
+		return decodeUTF16(bytes.TrimPrefix(data, utf16LEBOM), false), nil
+	case MetadataEncodingUTF16LE:
+		return decodeUTF16(bytes.TrimPrefix(data, utf16LEBOM), false), nil
+	case MetadataEncodingUTF16BE:
+		return decodeUTF16(bytes.TrimPrefix(data, utf16BEBOM), true), nil
+	case MetadataEncodingLatin1, "latin1", "iso-8859-1":
+		return decodeSingleByte(data, nil), nil
+	case MetadataEncodingWindows1252, "cp1252":
+		return decodeSingleByte(data, &windows1252), nil
+	default:
+		return "", fmt.Errorf("metadata-encoding \"%s\" not supported, please choose one of: %s", encoding, strings.Join(MetadataEncodings, ", "))
+	}
+}
+
+// parseMetadataDelimiter converts a --metadata-delimiter value to a rune, 0
+// means the delimiter should be detected
+func parseMetadataDelimiter(delimiter string) (rune, error) {
+	switch delimiter {
+	case "", "auto":
+		return 0, nil
+	case "tab", "\\t", "\t":
+		return '\t', nil
+	}
+	for _, d := range MetadataDelimiters {
+		if delimiter == d {
+			return []rune(delimiter)[0], nil
+		}
+	}
+	return 0, fmt.Errorf("metadata-delimiter \"%s\" not supported, please choose one of: %s", delimiter, strings.Join(MetadataDelimiters, ", "))
+}
+
+// CheckMetadataReadOptions returns an error if the encoding or delimiter of
+// options isn't supported
+func CheckMetadataReadOptions(options MetadataReadOptions) error {
+	if _, err := decodeMetadataText([]byte{}, options.Encoding); err != nil {
+		return err
+	}
+	_, err := parseMetadataDelimiter(options.Delimiter)
+	return err
+}
+
+// detectDelimiter returns the delimiter that splits the header line of text
+// into the most columns, ignoring delimiters in quotes. fallback is returned
+// if no delimiter is found.
+func detectDelimiter(text string, fallback rune) rune {
+	header := strings.TrimLeft(text, "\r\n")
+	inQuotes := false
+	counts := map[rune]int{}
+	for _, r := range header {
+		if r == '"' {
+			inQuotes = !inQuotes
+			continue
+		}
+		if !inQuotes && (r == '\n' || r == '\r') {
+			break
+		}
+		if !inQuotes {
+			counts[r]++
+		}
+	}
+	best, bestCount := fallback, counts[fallback]
+	for _, d := range detectedDelimiters {
+		if counts[d] > bestCount {
+			best, bestCount = d, counts[d]
+		}
+	}
+	return best
+}
+
+// readDelimitedRows reads the rows of a delimited metadata file after
+// converting it to UTF-8. The delimiter is detected unless options sets it,
+// defaultDelimiter is used if none is found. Rows may have a different number
+// of cells than the headers, like rows of .xlsx files, LintMetadataRows
+// reports them.
+func readDelimitedRows(path string, defaultDelimiter rune, options MetadataReadOptions) ([][]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	text, err := decodeMetadataText(data, options.Encoding)
+	if err != nil {
+		return nil, err
+	}
+	delimiter, err := parseMetadataDelimiter(options.Delimiter)
+	if err != nil {
+		return nil, err
+	}
+	if delimiter == 0 {
+		delimiter = detectDelimiter(text, defaultDelimiter)
+	}
+
+	reader := csv.NewReader(strings.NewReader(text))
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	return reader.ReadAll()
+}
@@ -0,0 +1,114 @@
+package czid
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"unicode/utf16"
+)
+
+func utf16Bytes(s string, bigEndian bool, bom bool) []byte {
+	units := utf16.Encode([]rune(s))
+	if bom {
+		units = append([]uint16{0xFEFF}, units...)
+	}
+	data := make([]byte, 0, len(units)*2)
+	for _, u := range units {
+		if bigEndian {
+			data = append(data, byte(u>>8), byte(u))
+		} else {
+			data = append(data, byte(u), byte(u>>8))
+		}
+	}
+	return data
+}
+
+func TestReadMetadataFileEncodings(t *testing.T) {
+	text := "Sample Name;Host Organism;Collection Location\nsample_1;Human;São Paulo, Brazil\n"
+	latin1 := []byte{}
+	for _, r := range text {
+		latin1 = append(latin1, byte(r))
+	}
+	tests := []struct {
+		name     string
+		data     []byte
+		encoding string
+	}{
+		{"utf-8.csv", []byte(text), ""},
+		{"utf-8-bom.csv", append([]byte{0xEF, 0xBB, 0xBF}, text...), ""},
+		{"utf-16le-bom.csv", utf16Bytes(text, false, true), ""},
+		{"utf-16be-bom.csv", utf16Bytes(text, true, true), ""},
+		{"utf-16le.csv", utf16Bytes(text, false, false), ""},
+		{"utf-16be.csv", utf16Bytes(text, true, false), MetadataEncodingUTF16BE},
+		{"latin-1.csv", latin1, ""},
+		{"latin-1-explicit.csv", latin1, MetadataEncodingLatin1},
+	}
+
+	dir := t.TempDir()
+	for _, test := range tests {
+		path := filepath.Join(dir, test.name)
+		if err := os.WriteFile(path, test.data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		samplesMetadata, err := ReadMetadataFile(path, MetadataReadOptions{Encoding: test.encoding})
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+		m, has := samplesMetadata["sample_1"]
+		if !has {
+			t.Errorf("%s: expected sample_1, got %v", test.name, samplesMetadata)
+			continue
+		}
+		if m.HostGenome != "Human" || m.rawCollectionLocation != "São Paulo, Brazil" {
+			t.Errorf("%s: unexpected metadata %v", test.name, m)
+		}
+	}
+}
+
+func TestReadMetadataFileDelimiter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metadata.csv")
+	// the header has more commas than semicolons in quotes, only the
+	// delimiter outside of quotes counts
+	contents := "Sample Name;\"Notes, comments, and more\"\nsample_1;a, b\n"
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	samplesMetadata, err := ReadMetadataFile(path, MetadataReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if samplesMetadata["sample_1"].fields["Notes, comments, and more"] != "a, b" {
+		t.Errorf("expected a detected semicolon delimiter, got %v", samplesMetadata)
+	}
+
+	_, err = ReadMetadataFile(path, MetadataReadOptions{Delimiter: ","})
+	if err == nil {
+		t.Error("expected an error reading with the wrong delimiter")
+	}
+}
+
+func TestCheckMetadataReadOptions(t *testing.T) {
+	if err := CheckMetadataReadOptions(MetadataReadOptions{Encoding: "utf-8", Delimiter: "tab"}); err != nil {
+		t.Error(err)
+	}
+	if err := CheckMetadataReadOptions(MetadataReadOptions{Encoding: "ebcdic"}); err == nil {
+		t.Error("expected an error for an unsupported encoding")
+	}
+	if err := CheckMetadataReadOptions(MetadataReadOptions{Delimiter: ":"}); err == nil {
+		t.Error("expected an error for an unsupported delimiter")
+	}
+}
+
+func TestDecodeMetadataTextWindows1252(t *testing.T) {
+	text, err := decodeMetadataText([]byte{0x93, 'h', 'i', 0x94, ' ', 0x80}, MetadataEncodingAuto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "“hi” €" {
+		t.Errorf("expected Windows-1252 text, got %s", text)
+	}
+	if _, err := decodeMetadataText([]byte{0x93}, MetadataEncodingUTF8); err == nil {
+		t.Error("expected an error for invalid UTF-8")
+	}
+}
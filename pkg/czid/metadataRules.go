@@ -0,0 +1,152 @@
+package czid
+
+// This file is for deriving metadata from input file paths and filling in
+// default values for empty metadata columns
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+
+	"github.com/spf13/viper"
+)
+
+// MetadataRules derive metadata values that are missing from the metadata
+// file and flags. Values from the metadata file and flags are never replaced.
+type MetadataRules struct {
+	// PathPatterns are regular expressions matched against each sample's
+	// input file paths, named capture groups like (?P<collection_date>...)
+	// become metadata columns. Earlier patterns take precedence.
+	PathPatterns []string
+	// Defaults are values for columns that are still empty after the path
+	// patterns are applied, keyed by column name
+	Defaults map[string]string
+}
+
+// WithConfig adds the path patterns and defaults under metadata_rules in
+// the configuration, rules set on the command line take precedence
+func (r MetadataRules) WithConfig() MetadataRules {
+	rules := MetadataRules{
+		PathPatterns: append(append([]string{}, r.PathPatterns...), viper.GetStringSlice("metadata_rules.path_patterns")...),
+		Defaults:     map[string]string{},
+	}
+	for _, defaults := range []map[string]string{viper.GetStringMapString("metadata_rules.defaults"), r.Defaults} {
+		for column, value := range defaults {
+			for existing := range rules.Defaults {
+				if normalizeHeader(existing) == normalizeHeader(column) {
+					delete(rules.Defaults, existing)
+				}
+			}
+			rules.Defaults[column] = value
+		}
+	}
+	return rules
+}
+
+func (r MetadataRules) compilePathPatterns() ([]*regexp.Regexp, error) {
+	patterns := make([]*regexp.Regexp, len(r.PathPatterns))
+	for i, pattern := range r.PathPatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path pattern %s: %s", pattern, err)
+		}
+		hasName := false
+		for _, name := range re.SubexpNames() {
+			hasName = hasName || name != ""
+		}
+		if !hasName {
+			return nil, fmt.Errorf("path pattern %s has no named capture groups like (?P<column>...)", pattern)
+		}
+		patterns[i] = re
+	}
+	return patterns, nil
+}
+
+// hasValue reports whether a sample has a non-empty value for a column,
+// comparing column names the way metadata headers are resolved
+func (m Metadata) hasValue(column string) bool {
+	if isHostGenomeHeader(column) {
+		return m.HostGenome != ""
+	}
+	if isCollectionLocationHeader(column) {
+		return m.rawCollectionLocation != "" || m.CollectionLocation != (GeoSearchSuggestion{})
+	}
+	normalized := normalizeHeader(column)
+	for k, v := range m.fields {
+		if normalizeHeader(k) == normalized && v != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// setValue sets a column's value, replacing an existing column with the same
+// normalized name so empty cells in the metadata file are filled in
+func (m Metadata) setValue(column string, value string) Metadata {
+	switch {
+	case isHostGenomeHeader(column):
+		column = "Host Organism"
+		for k := range m.fields {
+			if hostGenomeAliases[k] {
+				column = k
+			}
+		}
+	case isCollectionLocationHeader(column):
+		column = "Collection Location"
+	default:
+		normalized := normalizeHeader(column)
+		for k := range m.fields {
+			if normalizeHeader(k) == normalized {
+				column = k
+				break
+			}
+		}
+	}
+	return m.update(map[string]string{column: value})
+}
+
+// samplePaths returns the sequencing file paths of a sample with forward
+// slashes so patterns work the same on every platform
+func samplePaths(sampleFiles SampleFiles) []string {
+	paths := []string{}
+	for _, files := range [][]string{sampleFiles.R1, sampleFiles.R2, sampleFiles.Single, sampleFiles.BAM} {
+		for _, path := range files {
+			paths = append(paths, filepath.ToSlash(path))
+		}
+	}
+	return paths
+}
+
+// ApplyMetadataRules fills in samples' missing metadata from named capture
+// groups of the path patterns matched against their input files, then from
+// the default values
+func ApplyMetadataRules(samplesMetadata SamplesMetadata, sampleFiles map[string]SampleFiles, rules MetadataRules) error {
+	patterns, err := rules.compilePathPatterns()
+	if err != nil {
+		return err
+	}
+
+	for sampleName, m := range samplesMetadata {
+		for _, re := range patterns {
+			for _, path := range samplePaths(sampleFiles[sampleName]) {
+				match := re.FindStringSubmatch(path)
+				if match == nil {
+					continue
+				}
+				for i, column := range re.SubexpNames() {
+					if column != "" && match[i] != "" && !m.hasValue(column) {
+						m = m.setValue(column, match[i])
+					}
+				}
+				break
+			}
+		}
+		for column, value := range rules.Defaults {
+			if value != "" && !m.hasValue(column) {
+				m = m.setValue(column, value)
+			}
+		}
+		samplesMetadata[sampleName] = m
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package czid
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestApplyMetadataRules(t *testing.T) {
+	sampleFiles := map[string]SampleFiles{
+		"sample_1": {R1: []string{"run/SITE03_20230412_S5_R1_001.fastq.gz"}, R2: []string{"run/SITE03_20230412_S5_R2_001.fastq.gz"}},
+		"sample_2": {Single: []string{"run/SITE07_20230501_S6_R1_001.fastq.gz"}},
+		"sample_3": {Single: []string{"run/other.fastq.gz"}},
+	}
+	samplesMetadata := SamplesMetadata{
+		"sample_1": NewMetadata(map[string]string{"Site": "", "Sample Type": "Serum"}),
+		"sample_2": NewMetadata(map[string]string{"Site": "Clinic", "Host Organism": ""}),
+		"sample_3": NewMetadata(map[string]string{}),
+	}
+	rules := MetadataRules{
+		PathPatterns: []string{`(?P<site>SITE\d+)_(?P<collection_date>\d{8})_`},
+		Defaults:     map[string]string{"sample_type": "Nasopharyngeal Swab", "host_organism": "Human"},
+	}
+
+	err := ApplyMetadataRules(samplesMetadata, sampleFiles, rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sample1 := samplesMetadata["sample_1"]
+	if sample1.fields["Site"] != "SITE03" || sample1.fields["collection_date"] != "20230412" {
+		t.Errorf("expected captured site and collection date, got %v", sample1.fields)
+	}
+	if sample1.fields["Sample Type"] != "Serum" || sample1.fields["sample_type"] != "" {
+		t.Errorf("expected sample type from the metadata file to be kept, got %v", sample1.fields)
+	}
+	if sample1.HostGenome != "Human" {
+		t.Errorf("expected default host organism, got '%s'", sample1.HostGenome)
+	}
+
+	sample2 := samplesMetadata["sample_2"]
+	if sample2.fields["Site"] != "Clinic" || sample2.fields["collection_date"] != "20230501" {
+		t.Errorf("expected site from the metadata file and captured collection date, got %v", sample2.fields)
+	}
+	if sample2.HostGenome != "Human" || sample2.fields["Host Organism"] != "Human" {
+		t.Errorf("expected empty host organism to be filled in, got %v", sample2.fields)
+	}
+
+	sample3 := samplesMetadata["sample_3"]
+	if _, has := sample3.fields["site"]; has {
+		t.Errorf("expected no captures for an unmatched path, got %v", sample3.fields)
+	}
+	if sample3.fields["sample_type"] != "Nasopharyngeal Swab" {
+		t.Errorf("expected default sample type, got %v", sample3.fields)
+	}
+}
+
+func TestApplyMetadataRulesInvalidPattern(t *testing.T) {
+	for _, pattern := range []string{`(?P<site>SITE\d+`, `SITE\d+`} {
+		err := ApplyMetadataRules(SamplesMetadata{}, map[string]SampleFiles{}, MetadataRules{PathPatterns: []string{pattern}})
+		if err == nil {
+			t.Errorf("expected an error for path pattern %s", pattern)
+		}
+	}
+}
+
+func TestMetadataRulesWithConfig(t *testing.T) {
+	defer viper.Set("metadata_rules", nil)
+	viper.Set("metadata_rules", map[string]interface{}{
+		"path_patterns": []string{`(?P<site>SITE\d+)`},
+		"defaults":      map[string]string{"sample type": "Serum", "nucleotide type": "RNA"},
+	})
+
+	rules := MetadataRules{
+		PathPatterns: []string{`(?P<plate>P\d+)`},
+		Defaults:     map[string]string{"Sample Type": "Nasopharyngeal Swab"},
+	}.WithConfig()
+
+	if len(rules.PathPatterns) != 2 || rules.PathPatterns[0] != `(?P<plate>P\d+)` {
+		t.Errorf("expected command line patterns before config patterns, got %v", rules.PathPatterns)
+	}
+	if len(rules.Defaults) != 2 || rules.Defaults["Sample Type"] != "Nasopharyngeal Swab" || rules.Defaults["nucleotide type"] != "RNA" {
+		t.Errorf("expected command line defaults to take precedence, got %v", rules.Defaults)
+	}
+}
@@ -0,0 +1,75 @@
+package czid
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/chanzuckerberg/czid-cli/pkg/util"
+)
+
+type cachedMetadataSchema struct {
+	HostGenome string
+	FetchedAt  time.Time
+	Fields     []MetadataField
+}
+
+func metadataSchemaCacheName(hostGenome string) string {
+	return path.Join("metadata_schemas", url.PathEscape(strings.ToLower(hostGenome))+".json")
+}
+
+// GetMetadataSchema returns the metadata fields for a host organism. Fields
+// are fetched from CZ ID and cached, if offline is true or CZ ID can't be
+// reached the cached fields are used.
+func (c *Client) GetMetadataSchema(hostGenome string, offline bool) ([]MetadataField, error) {
+	cacheName := metadataSchemaCacheName(hostGenome)
+	if !offline {
+		fields, err := c.GetMetadataForHostGenome(hostGenome)
+		if err == nil {
+			cacheErr := util.WriteJSONCache(cacheName, cachedMetadataSchema{
+				HostGenome: hostGenome,
+				FetchedAt:  time.Now(),
+				Fields:     fields,
+			})
+			if cacheErr != nil {
+				fmt.Fprintf(os.Stderr, "warning: could not cache metadata fields for host organism '%s': %s\n", hostGenome, cacheErr)
+			}
+			return fields, nil
+		}
+		fmt.Fprintf(os.Stderr, "warning: could not fetch metadata fields for host organism '%s', using cached fields: %s\n", hostGenome, err)
+	}
+
+	var cached cachedMetadataSchema
+	found, err := util.ReadJSONCache(cacheName, &cached)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("no cached metadata fields for host organism '%s', run once while online to cache them", hostGenome)
+	}
+	return cached.Fields, nil
+}
+
+// GetMetadataSchemas returns the metadata fields of every host organism in
+// samplesMetadata keyed by lower case host organism name
+func (c *Client) GetMetadataSchemas(samplesMetadata SamplesMetadata, offline bool) (map[string][]MetadataField, error) {
+	schemas := map[string][]MetadataField{}
+	for _, m := range samplesMetadata {
+		hostGenome := strings.ToLower(m.HostGenome)
+		if hostGenome == "" {
+			continue
+		}
+		if _, has := schemas[hostGenome]; has {
+			continue
+		}
+		fields, err := c.GetMetadataSchema(m.HostGenome, offline)
+		if err != nil {
+			return schemas, err
+		}
+		schemas[hostGenome] = fields
+	}
+	return schemas, nil
+}
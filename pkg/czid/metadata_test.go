@@ -206,3 +206,62 @@ func TestFallbackToRawMetadataWithNoSuggestion(t *testing.T) {
 		t.Fatalf("")
 	}
 }
+
+func TestReadMetadataFileTSV(t *testing.T) {
+	tsv, err := os.CreateTemp("", "*.tsv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(tsv.Name())
+	_, err = tsv.WriteString("Sample Name\tHost Genome\tcollection_location\n" +
+		"sample one\tHuman\tCalifornia, USA\n" +
+		"sample two\tDog\t\n")
+	tsv.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	samplesMetadata, err := ReadMetadataFile(tsv.Name(), MetadataReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !samplesMetadata["sample one"].isHuman() {
+		t.Errorf("expected sample one to be human but isHuman was false")
+	}
+	if samplesMetadata["sample one"].rawCollectionLocation != "California, USA" {
+		t.Errorf("expected rawCollectionLocation to be \"California, USA\" but it was \"%s\"", samplesMetadata["sample one"].rawCollectionLocation)
+	}
+	if samplesMetadata["sample two"].HostGenome != "Dog" {
+		t.Errorf("expected sample two host genome to be \"Dog\" but it was \"%s\"", samplesMetadata["sample two"].HostGenome)
+	}
+}
+
+func TestReadMetadataFileKeyBySampleID(t *testing.T) {
+	csv, err := os.CreateTemp("", "*.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(csv.Name())
+	_, err = csv.WriteString("Sample ID,Host Organism\n12,Human\n")
+	csv.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = ReadMetadataFile(csv.Name(), MetadataReadOptions{})
+	if err == nil || !strings.Contains(err.Error(), "'Sample Name' is required") {
+		t.Errorf("expected a missing Sample Name error but got %v", err)
+	}
+
+	samplesMetadata, err := ReadMetadataFile(csv.Name(), MetadataReadOptions{KeyBySampleID: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if samplesMetadata["12"].HostGenome != "Human" {
+		t.Errorf("expected the sample to be keyed by its sample ID but got %v", samplesMetadata)
+	}
+	matched, unmatched := MatchProjectSamples(samplesMetadata, []ProjectSample{{ID: 12, Name: "sample twelve"}})
+	if _, has := matched["sample twelve"]; !has || len(unmatched) > 0 {
+		t.Errorf("expected the row to be matched by its sample ID but got %v, unmatched %v", matched, unmatched)
+	}
+}
@@ -0,0 +1,137 @@
+package czid
+
+// This file is for finding protected health information in the metadata of
+// human samples before they are created
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PHIOptions control the protected health information check
+type PHIOptions struct {
+	// AllowColumns are columns the user has confirmed are de-identified,
+	// they are not checked
+	AllowColumns []string
+}
+
+// phiCheck finds one kind of protected health information in a metadata
+// value, column is normalized with normalizeHeader
+type phiCheck struct {
+	description string
+	matches     func(column string, value string) bool
+}
+
+var (
+	honorificName   = regexp.MustCompile(`\b(Mr|Mrs|Ms|Miss|Dr)\.?\s+[A-Z][a-z]+`)
+	labeledName     = regexp.MustCompile(`(?i)\b(patient|(patient|first|last|full|given) name)\s*[:=]\s*\S+`)
+	labeledMRN      = regexp.MustCompile(`(?i)\b(mrn|medical record (number|no\.?|#))\s*[:#=]?\s*\w*\d`)
+	phoneNumber     = regexp.MustCompile(`(\+\d{1,2}[\s.-]?)?(\(\d{3}\)\s?|\b\d{3}[\s.-])\d{3}[\s.-]\d{4}\b`)
+	labeledBirthday = regexp.MustCompile(`(?i)\b(dob|d\.o\.b\.|date of birth|birth ?date|born)\s*[:=]?\s*\d`)
+	ageInYears      = regexp.MustCompile(`(?i)^(\d+(\.\d+)?)\s*(y|yr|yrs|years?)?$`)
+)
+
+// columnHasWord reports whether a normalized column name contains any of the
+// words or phrases as whole words
+func columnHasWord(column string, words ...string) bool {
+	padded := " " + column + " "
+	for _, word := range words {
+		if strings.Contains(padded, " "+word+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+var phiChecks = []phiCheck{
+	{"personal name", func(column string, value string) bool {
+		return column == "patient" || columnHasWord(column, "patient name", "first name", "last name", "full name", "given name", "surname", "family name") ||
+			honorificName.MatchString(value) ||
+			labeledName.MatchString(value)
+	}},
+	{"medical record number", func(column string, value string) bool {
+		return columnHasWord(column, "mrn", "medical record") || labeledMRN.MatchString(value)
+	}},
+	{"phone number", func(column string, value string) bool {
+		return columnHasWord(column, "phone", "telephone", "mobile") || phoneNumber.MatchString(value)
+	}},
+	{"exact date of birth", func(column string, value string) bool {
+		if columnHasWord(column, "dob", "birth", "birthday", "birthdate", "birth date") {
+			date, ok := NormalizeDate(value, DateOrderMonthFirst, false)
+			return ok && len(date) == len("2006-01-02")
+		}
+		return labeledBirthday.MatchString(value)
+	}},
+	{"age over 89", func(column string, value string) bool {
+		if !columnHasWord(column, "age") {
+			return false
+		}
+		match := ageInYears.FindStringSubmatch(value)
+		if match == nil {
+			return false
+		}
+		age, err := strconv.ParseFloat(match[1], 64)
+		return err == nil && age > 89
+	}},
+}
+
+// CheckPHI reports metadata values of human samples that look like protected
+// health information: personal names, medical record numbers, phone numbers,
+// exact dates of birth, and ages over 89. CZ ID expects the metadata of human
+// samples to be de-identified so every finding is an error. Columns in
+// options.AllowColumns, host organisms, and collection locations are not
+// checked.
+func CheckPHI(samplesMetadata SamplesMetadata, options PHIOptions) []MetadataIssue {
+	allowed := make(map[string]bool, len(options.AllowColumns))
+	for _, column := range options.AllowColumns {
+		allowed[normalizeHeader(column)] = true
+	}
+
+	sampleNames := make([]string, 0, len(samplesMetadata))
+	for sampleName := range samplesMetadata {
+		sampleNames = append(sampleNames, sampleName)
+	}
+	sort.Strings(sampleNames)
+
+	issues := []MetadataIssue{}
+	for _, sampleName := range sampleNames {
+		m := samplesMetadata[sampleName]
+		if !m.isHuman() {
+			continue
+		}
+		columns := make([]string, 0, len(m.fields))
+		for column := range m.fields {
+			columns = append(columns, column)
+		}
+		sort.Strings(columns)
+
+		for _, column := range columns {
+			value := strings.TrimSpace(m.fields[column])
+			normalized := normalizeHeader(column)
+			if value == "" || allowed[normalized] || isHostGenomeHeader(column) || isCollectionLocationHeader(column) {
+				continue
+			}
+			for _, check := range phiChecks {
+				if !check.matches(normalized, value) {
+					continue
+				}
+				issues = append(issues, MetadataIssue{
+					Severity: IssueError,
+					Caption: fmt.Sprintf(
+						"possible %s in the metadata of a human sample, remove it or allow the column with --allow-phi-column '%s' if it is de-identified",
+						check.description,
+						column,
+					),
+					SampleName: sampleName,
+					Column:     column,
+					Value:      value,
+				})
+				break
+			}
+		}
+	}
+	return issues
+}
@@ -0,0 +1,75 @@
+package czid
+
+import (
+	"testing"
+)
+
+func TestCheckPHI(t *testing.T) {
+	tests := []struct {
+		column      string
+		value       string
+		description string
+	}{
+		{"Patient Name", "Jane Doe", "personal name"},
+		{"Notes", "seen by Dr. Smith", "personal name"},
+		{"Notes", "patient: jdoe", "personal name"},
+		{"MRN", "A1234", "medical record number"},
+		{"Notes", "MRN# 0012345", "medical record number"},
+		{"Notes", "call (555) 123-4567", "phone number"},
+		{"Contact Phone", "ask front desk", "phone number"},
+		{"Date of Birth", "3/4/1950", "exact date of birth"},
+		{"Notes", "DOB: 1950-03-04", "exact date of birth"},
+		{"Host Age", "92", "age over 89"},
+		{"Host Age", "95 years", "age over 89"},
+		{"Host Age", "89", ""},
+		{"Host Age", "90+", ""},
+		{"Date of Birth", "1950", ""},
+		{"Patient ID", "P-0042", ""},
+		{"Collection Date", "2023-04-12", ""},
+		{"Sample Type", "Nasopharyngeal Swab", ""},
+		{"Ct Value", "23.5", ""},
+	}
+	for _, test := range tests {
+		samplesMetadata := SamplesMetadata{
+			"sample": NewMetadata(map[string]string{"Host Organism": "Human", test.column: test.value}),
+		}
+		issues := CheckPHI(samplesMetadata, PHIOptions{})
+		if test.description == "" {
+			if len(issues) != 0 {
+				t.Errorf("expected no issues for %s '%s', got %v", test.column, test.value, issues)
+			}
+			continue
+		}
+		if len(issues) != 1 {
+			t.Errorf("expected 1 issue for %s '%s', got %v", test.column, test.value, issues)
+			continue
+		}
+		expectedCaption := "possible " + test.description + " in the metadata of a human sample, remove it or allow the column with --allow-phi-column '" + test.column + "' if it is de-identified"
+		if issues[0].Caption != expectedCaption || issues[0].Severity != IssueError || issues[0].Column != test.column || issues[0].Value != test.value {
+			t.Errorf("unexpected issue for %s '%s': %v", test.column, test.value, issues[0])
+		}
+	}
+}
+
+func TestCheckPHIOnlyHumanSamples(t *testing.T) {
+	samplesMetadata := SamplesMetadata{
+		"sample": NewMetadata(map[string]string{"Host Organism": "Mosquito", "Notes": "call 555-123-4567"}),
+	}
+	if issues := CheckPHI(samplesMetadata, PHIOptions{}); len(issues) != 0 {
+		t.Errorf("expected non-human samples not to be checked, got %v", issues)
+	}
+}
+
+func TestCheckPHIAllowColumns(t *testing.T) {
+	samplesMetadata := SamplesMetadata{
+		"sample": NewMetadata(map[string]string{
+			"Host Organism": "Human",
+			"Notes":         "call 555-123-4567",
+			"Host Age":      "93",
+		}),
+	}
+	issues := CheckPHI(samplesMetadata, PHIOptions{AllowColumns: []string{"notes"}})
+	if len(issues) != 1 || issues[0].Column != "Host Age" {
+		t.Errorf("expected only the host age to be reported, got %v", issues)
+	}
+}
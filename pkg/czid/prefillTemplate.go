@@ -0,0 +1,111 @@
+package czid
+
+import (
+	"sort"
+)
+
+// PrefillReport describes how existing metadata was joined to the rows of a
+// metadata template
+type PrefillReport struct {
+	// Matches are the metadata rows matched to template samples
+	Matches []SampleNameMatch
+	// UnmatchedRows are the metadata rows that matched no template sample
+	UnmatchedRows []string
+	// UnfilledSamples are the template samples no metadata row matched
+	UnfilledSamples []string
+	// DroppedColumns are the columns of the metadata that aren't in the
+	// template and were left out
+	DroppedColumns []string
+}
+
+// PrefillTemplateRows fills the empty cells of metadata template rows with
+// existing metadata. The first row is the template's headers and must have a
+// "Sample Name" column. Rows of existing metadata are matched to template
+// rows with MatchSampleNames. Columns of the existing metadata that aren't in
+// the template are dropped and reported, unless keepExtraColumns is true, then
+// they are added after the template's columns so the template's column order
+// is kept.
+func PrefillTemplateRows(rows [][]string, prefill SamplesMetadata, keepExtraColumns bool) ([][]string, PrefillReport) {
+	report := PrefillReport{Matches: []SampleNameMatch{}, UnmatchedRows: []string{}, UnfilledSamples: []string{}, DroppedColumns: []string{}}
+	if len(rows) == 0 {
+		return rows, report
+	}
+	headers := append([]string{}, rows[0]...)
+	sampleNameIdx := -1
+	headerIdx := make(map[string]int, len(headers))
+	for i, header := range headers {
+		headerIdx[normalizeHeader(header)] = i
+		if header == "Sample Name" {
+			sampleNameIdx = i
+		}
+	}
+	if sampleNameIdx == -1 {
+		return rows, report
+	}
+
+	sampleNames := make([]string, 0, len(rows)-1)
+	for _, row := range rows[1:] {
+		if sampleNameIdx < len(row) {
+			sampleNames = append(sampleNames, row[sampleNameIdx])
+		}
+	}
+	names := make([]string, 0, len(prefill))
+	for name := range prefill {
+		names = append(names, name)
+	}
+	report.Matches, report.UnmatchedRows = MatchSampleNames(names, sampleNames)
+
+	values := make(map[string]map[string]string, len(report.Matches))
+	extraColumns := map[string]string{}
+	for _, match := range report.Matches {
+		sampleValues := map[string]string{}
+		for column, value := range prefill[match.Name].values() {
+			normalized := normalizeHeader(column)
+			if value == "" || sampleNameAliases[column] || normalized == "sample id" {
+				continue
+			}
+			sampleValues[normalized] = value
+			if _, has := headerIdx[normalized]; !has {
+				extraColumns[normalized] = column
+			}
+		}
+		values[match.Sample] = sampleValues
+	}
+
+	extra := make([]string, 0, len(extraColumns))
+	for normalized := range extraColumns {
+		extra = append(extra, normalized)
+	}
+	sort.Strings(extra)
+	for _, normalized := range extra {
+		if !keepExtraColumns {
+			report.DroppedColumns = append(report.DroppedColumns, extraColumns[normalized])
+			continue
+		}
+		headerIdx[normalized] = len(headers)
+		headers = append(headers, extraColumns[normalized])
+	}
+
+	filled := make([][]string, 0, len(rows))
+	filled = append(filled, headers)
+	for _, row := range rows[1:] {
+		filledRow := make([]string, len(headers))
+		copy(filledRow, row)
+		sampleName := ""
+		if sampleNameIdx < len(row) {
+			sampleName = row[sampleNameIdx]
+		}
+		sampleValues, has := values[sampleName]
+		if !has {
+			report.UnfilledSamples = append(report.UnfilledSamples, sampleName)
+		}
+		for normalized, value := range sampleValues {
+			if idx, has := headerIdx[normalized]; has && filledRow[idx] == "" {
+				filledRow[idx] = value
+			}
+		}
+		filled = append(filled, filledRow)
+	}
+	sort.Strings(report.UnfilledSamples)
+	return filled, report
+}
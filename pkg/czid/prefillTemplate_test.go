@@ -0,0 +1,65 @@
+package czid
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPrefillTemplateRows(t *testing.T) {
+	rows := [][]string{
+		{"Sample Name", "Host Organism", "Sample Type", "Collection Date"},
+		{"S01", "Human", "", ""},
+		{"sample_2", "", "", "2023-01-01"},
+		{"sample_3", "Human", "", ""},
+	}
+	prefill := SamplesMetadata{
+		"S-01":     NewMetadata(map[string]string{"sample_type": "Serum", "Freezer Box": "B4", "Host Organism": "Mosquito"}),
+		"sample_2": NewMetadata(map[string]string{"Host Organism": "Mosquito", "Collection Date": "2022-12-31", "Sample ID": "12"}),
+		"other":    NewMetadata(map[string]string{"Sample Type": "CSF"}),
+	}
+
+	filled, report := PrefillTemplateRows(rows, prefill, false)
+	expected := [][]string{
+		{"Sample Name", "Host Organism", "Sample Type", "Collection Date"},
+		{"S01", "Human", "Serum", ""},
+		{"sample_2", "Mosquito", "", "2023-01-01"},
+		{"sample_3", "Human", "", ""},
+	}
+	if !reflect.DeepEqual(filled, expected) {
+		t.Errorf("expected %v, got %v", expected, filled)
+	}
+	if !reflect.DeepEqual(report.DroppedColumns, []string{"Freezer Box"}) {
+		t.Errorf("expected dropped column 'Freezer Box', got %v", report.DroppedColumns)
+	}
+	if len(report.Matches) != 2 || report.Matches[0].Reason != SampleNameMatchedNormalized {
+		t.Errorf("unexpected matches %v", report.Matches)
+	}
+	if !reflect.DeepEqual(report.UnmatchedRows, []string{"other"}) {
+		t.Errorf("expected unmatched row 'other', got %v", report.UnmatchedRows)
+	}
+	if !reflect.DeepEqual(report.UnfilledSamples, []string{"sample_3"}) {
+		t.Errorf("expected unfilled sample 'sample_3', got %v", report.UnfilledSamples)
+	}
+}
+
+func TestPrefillTemplateRowsExtraColumns(t *testing.T) {
+	rows := [][]string{
+		{"Sample Name", "Sample Type"},
+		{"S01", ""},
+	}
+	prefill := SamplesMetadata{
+		"S01": NewMetadata(map[string]string{"Sample Type": "Serum", "Freezer Box": "B4"}),
+	}
+
+	filled, report := PrefillTemplateRows(rows, prefill, true)
+	expected := [][]string{
+		{"Sample Name", "Sample Type", "Freezer Box"},
+		{"S01", "Serum", "B4"},
+	}
+	if !reflect.DeepEqual(filled, expected) {
+		t.Errorf("expected %v, got %v", expected, filled)
+	}
+	if len(report.DroppedColumns) != 0 {
+		t.Errorf("expected no dropped columns, got %v", report.DroppedColumns)
+	}
+}
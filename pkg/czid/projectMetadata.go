@@ -0,0 +1,238 @@
+package czid
+
+// This file is for reading and updating the metadata of samples that have
+// already been uploaded to a project
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// projectSamplesPageSize is the number of samples requested at a time
+const projectSamplesPageSize = 100
+
+// ProjectSample is a sample that has been uploaded to a project along with
+// its metadata keyed by metadata field name
+type ProjectSample struct {
+	ID         int
+	Name       string
+	HostGenome string
+	Metadata   map[string]string
+	// CollectionLocation is the sample's collection location as CZ ID stores
+	// it, its name is in Metadata
+	CollectionLocation GeoSearchSuggestion
+}
+
+type getProjectSamplesReq struct{}
+
+type getProjectSamplesResSample struct {
+	ID      int    `json:"id"`
+	Name    string `json:"name"`
+	Details struct {
+		DBSample struct {
+			HostGenomeName string `json:"host_genome_name"`
+		} `json:"db_sample"`
+		Metadata map[string]interface{} `json:"metadata"`
+	} `json:"details"`
+}
+
+type getProjectSamplesRes struct {
+	Samples []getProjectSamplesResSample `json:"samples"`
+}
+
+// metadataValueFromAPI converts a metadata value returned by CZ ID to a
+// string, locations are converted to their name
+func metadataValueFromAPI(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case map[string]interface{}:
+		if name, ok := value["name"].(string); ok {
+			return name
+		}
+		return ""
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// locationFromAPI converts a location returned by CZ ID to a
+// GeoSearchSuggestion, ok is false if v isn't a location
+func locationFromAPI(v interface{}) (location GeoSearchSuggestion, ok bool) {
+	if _, isMap := v.(map[string]interface{}); !isMap {
+		return location, false
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return location, false
+	}
+	if err := json.Unmarshal(b, &location); err != nil {
+		return location, false
+	}
+	return location, location != (GeoSearchSuggestion{})
+}
+
+// GetProjectSamples returns every sample in a project with its metadata.
+// Metadata is keyed by the field names of each sample's host organism so it
+// matches the columns of metadata files.
+func (c *Client) GetProjectSamples(projectID int) ([]ProjectSample, error) {
+	samples := []ProjectSample{}
+	schemas := map[string][]MetadataField{}
+	for offset := 0; ; offset += projectSamplesPageSize {
+		query := url.Values{
+			"projectId": []string{strconv.Itoa(projectID)},
+			"limit":     []string{strconv.Itoa(projectSamplesPageSize)},
+			"offset":    []string{strconv.Itoa(offset)},
+		}
+		var res getProjectSamplesRes
+		err := c.request("GET", "/samples/index_v2.json", query.Encode(), getProjectSamplesReq{}, &res)
+		if err != nil {
+			return samples, err
+		}
+
+		for _, s := range res.Samples {
+			hostGenome := s.Details.DBSample.HostGenomeName
+			fields, has := schemas[strings.ToLower(hostGenome)]
+			if !has && hostGenome != "" {
+				fields, err = c.GetMetadataSchema(hostGenome, false)
+				if err != nil {
+					return samples, err
+				}
+				schemas[strings.ToLower(hostGenome)] = fields
+			}
+			names := make(map[string]string, len(fields))
+			for _, f := range fields {
+				names[normalizeHeader(f.Key)] = f.Name
+			}
+
+			metadata := make(map[string]string, len(s.Details.Metadata))
+			var location GeoSearchSuggestion
+			for key, v := range s.Details.Metadata {
+				if l, ok := locationFromAPI(v); ok {
+					location = l
+				}
+				value := metadataValueFromAPI(v)
+				if value == "" {
+					continue
+				}
+				if name, has := names[normalizeHeader(key)]; has {
+					key = name
+				}
+				metadata[key] = value
+			}
+			samples = append(samples, ProjectSample{
+				ID:                 s.ID,
+				Name:               s.Name,
+				HostGenome:         hostGenome,
+				Metadata:           metadata,
+				CollectionLocation: location,
+			})
+		}
+		if len(res.Samples) < projectSamplesPageSize {
+			break
+		}
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i].Name < samples[j].Name })
+	return samples, nil
+}
+
+// metadataTable converts samples' metadata to headers and rows for CZ ID's
+// metadata endpoints, columns are sorted and every row has every column
+func metadataTable(samplesMetadata SamplesMetadata) validateCSVReqMetadata {
+	headerSet := map[string]bool{}
+	hasLocation := false
+	for _, m := range samplesMetadata {
+		for header := range m.fields {
+			headerSet[header] = true
+		}
+		if m.rawCollectionLocation != "" || m.CollectionLocation != (GeoSearchSuggestion{}) {
+			hasLocation = true
+		}
+	}
+	headers := make([]string, 0, len(headerSet))
+	for header := range headerSet {
+		headers = append(headers, header)
+	}
+	sort.Strings(headers)
+	table := validateCSVReqMetadata{Headers: append([]string{"Sample Name"}, headers...)}
+	if hasLocation {
+		table.Headers = append(table.Headers, "Collection Location")
+	}
+
+	sampleNames := make([]string, 0, len(samplesMetadata))
+	for sampleName := range samplesMetadata {
+		sampleNames = append(sampleNames, sampleName)
+	}
+	sort.Strings(sampleNames)
+	table.Rows = make([][]interface{}, 0, len(sampleNames))
+	for _, sampleName := range sampleNames {
+		m := samplesMetadata[sampleName]
+		row := make([]interface{}, 0, len(table.Headers))
+		row = append(row, sampleName)
+		for _, header := range headers {
+			row = append(row, m.fields[header])
+		}
+		if hasLocation {
+			if m.CollectionLocation != (GeoSearchSuggestion{}) {
+				row = append(row, m.CollectionLocation)
+			} else {
+				row = append(row, m.rawCollectionLocation)
+			}
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	return table
+}
+
+type projectMetadataReq struct {
+	ID       int                    `json:"id"`
+	Metadata validateCSVReqMetadata `json:"metadata"`
+}
+
+// ValidateProjectMetadata validates updated metadata for samples already in a
+// project with CZ ID, printing and returning every issue found
+func (c *Client) ValidateProjectMetadata(projectID int, samplesMetadata SamplesMetadata) ([]MetadataIssue, error) {
+	req := projectMetadataReq{ID: projectID, Metadata: metadataTable(samplesMetadata)}
+	var res validateCSVRes
+	err := c.request("POST", "/metadata/validate_csv_for_project.json", "", req, &res)
+	if err != nil {
+		return []MetadataIssue{}, err
+	}
+	res.Issues.friendlyPrint()
+	issues := res.Issues.metadataIssues()
+	if len(res.Issues.Errors) > 0 {
+		return issues, ErrMetadataValidationFailed
+	}
+	return issues, nil
+}
+
+type uploadProjectMetadataRes struct {
+	Status string        `json:"status"`
+	Errors []interface{} `json:"errors"`
+}
+
+// UploadProjectMetadata updates the metadata of samples already in a project
+func (c *Client) UploadProjectMetadata(projectID int, samplesMetadata SamplesMetadata) error {
+	req := projectMetadataReq{ID: projectID, Metadata: metadataTable(samplesMetadata)}
+	var res uploadProjectMetadataRes
+	err := c.request("POST", "/metadata/upload.json", "", req, &res)
+	if err != nil {
+		return err
+	}
+	if len(res.Errors) > 0 {
+		messages := make([]string, len(res.Errors))
+		for i, e := range res.Errors {
+			messages[i] = fmt.Sprint(e)
+		}
+		return fmt.Errorf("metadata update failed:\n  %s", strings.Join(messages, "\n  "))
+	}
+	return nil
+}
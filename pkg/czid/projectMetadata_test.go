@@ -0,0 +1,105 @@
+package czid
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetProjectSamples(t *testing.T) {
+	response := []byte(`{"samples": [
+	  {"id": 2, "name": "sample two", "details": {"metadata": {"ct_value": 21.5, "collection_location_v2": {"name": "California, USA"}, "notes": null}}},
+	  {"id": 1, "name": "sample one", "details": {"metadata": {"sample_type": "Blood"}}}
+	]}`)
+	httpClient := newMockHTTPClient(response)
+	apiClient := Client{auth0: &mockAuth0Client{}, httpClient: &httpClient}
+
+	samples, err := apiClient.GetProjectSamples(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []ProjectSample{
+		{ID: 1, Name: "sample one", Metadata: map[string]string{"sample_type": "Blood"}},
+		{
+			ID:                 2,
+			Name:               "sample two",
+			Metadata:           map[string]string{"ct_value": "21.5", "collection_location_v2": "California, USA"},
+			CollectionLocation: GeoSearchSuggestion{Name: "California, USA"},
+		},
+	}
+	if !reflect.DeepEqual(samples, expected) {
+		t.Errorf("expected %+v but got %+v", expected, samples)
+	}
+	if query := httpClient.calls[0].URL.Query(); query.Get("projectId") != "7" || query.Get("offset") != "0" {
+		t.Errorf("unexpected query %v", query)
+	}
+}
+
+var testProjectSamples = []ProjectSample{
+	{
+		ID:         1,
+		Name:       "sample one",
+		HostGenome: "Human",
+		Metadata:   map[string]string{"Sample Type": "Blood", "Ct Value": "30", "Collection Location": "California, USA"},
+		CollectionLocation: GeoSearchSuggestion{
+			Name:        "California, USA",
+			GeoLevel:    "state",
+			CountryName: "USA",
+			StateName:   "California",
+			CountryCode: "us",
+		},
+	},
+	{ID: 2, Name: "sample two", HostGenome: "Human", Metadata: map[string]string{"Sample Type": "Nasal Swab", "Notes": "rerun"}},
+}
+
+func TestMatchProjectSamplesAndDiff(t *testing.T) {
+	samplesMetadata := SamplesMetadata{
+		"sample one": NewMetadata(map[string]string{"Ct Value": "21.5", "Collection Location": "California, USA"}),
+		"2":          NewMetadata(map[string]string{"Sample ID": "2", "sample type": "Nasal Swab", "Notes": "", "Ct Value": "18"}),
+		"sample six": NewMetadata(map[string]string{"Ct Value": "18"}),
+		"1":          NewMetadata(map[string]string{"Ct Value": "40"}),
+	}
+
+	matched, unmatched := MatchProjectSamples(samplesMetadata, testProjectSamples)
+	if !reflect.DeepEqual(unmatched, []string{"1", "sample six"}) {
+		t.Errorf("expected '1' and 'sample six' to be unmatched but got %v", unmatched)
+	}
+	if matched["sample two"].HostGenome != "Human" {
+		t.Errorf("expected sample two to be matched by ID and get its host organism but got %+v", matched)
+	}
+
+	expected := []MetadataChange{
+		{Kind: MetadataChanged, SampleName: "sample one", Column: "Ct Value", Old: "30", New: "21.5"},
+		{Kind: MetadataAdded, SampleName: "sample two", Column: "Ct Value", New: "18"},
+		{Kind: MetadataRemoved, SampleName: "sample two", Column: "Notes", Old: "rerun"},
+	}
+	changes := DiffMetadata(testProjectSamples, matched, false)
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("expected %+v but got %+v", expected, changes)
+	}
+
+	changes = DiffMetadata(testProjectSamples, matched, true)
+	removed := MetadataChange{Kind: MetadataRemoved, SampleName: "sample one", Column: "Sample Type", Old: "Blood"}
+	if len(changes) != 4 || changes[1] != removed {
+		t.Errorf("expected missing columns to be reported as removed but got %+v", changes)
+	}
+}
+
+func TestFillMissingMetadataAndTable(t *testing.T) {
+	samplesMetadata := SamplesMetadata{
+		"sample one": NewMetadata(map[string]string{"Ct Value": "21.5"}),
+		"sample two": NewMetadata(map[string]string{"Notes": "ok", "Collection Location": "Oregon, USA"}),
+	}
+	FillMissingMetadata(samplesMetadata, testProjectSamples)
+
+	table := metadataTable(samplesMetadata)
+	expected := validateCSVReqMetadata{
+		Headers: []string{"Sample Name", "Ct Value", "Notes", "Collection Location"},
+		Rows: [][]interface{}{
+			{"sample one", "21.5", "", testProjectSamples[0].CollectionLocation},
+			{"sample two", "", "ok", "Oregon, USA"},
+		},
+	}
+	if !reflect.DeepEqual(table, expected) {
+		t.Errorf("expected %+v but got %+v", expected, table)
+	}
+}
@@ -0,0 +1,195 @@
+package czid
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/chanzuckerberg/czid-cli/pkg/util"
+	"gopkg.in/yaml.v2"
+)
+
+// reasons a metadata column was mapped to a metadata field
+const (
+	HeaderMappedByAlias   = "alias file"
+	HeaderMappedByVariant = "case and spacing"
+	HeaderMappedByFuzzy   = "close match"
+)
+
+// HeaderResolverOptions control how metadata columns are matched to the
+// metadata fields of CZ ID's metadata dictionary
+type HeaderResolverOptions struct {
+	// AliasesPath is an optional YAML or JSON file mapping column names to
+	// metadata field names
+	AliasesPath string
+	// DisableFuzzy turns off matching misspelled column names, case and
+	// spacing variants are still matched
+	DisableFuzzy bool
+}
+
+// HeaderMapping is a metadata column renamed to a metadata field
+type HeaderMapping struct {
+	From   string
+	To     string
+	Reason string
+}
+
+func (m HeaderMapping) String() string {
+	return fmt.Sprintf("mapped metadata column '%s' to '%s' (%s)", m.From, m.To, m.Reason)
+}
+
+// ReadHeaderAliases reads a YAML or JSON file mapping column names to metadata
+// field names, keys are matched ignoring case and spacing
+func ReadHeaderAliases(path string) (map[string]string, error) {
+	aliases := map[string]string{}
+	if path == "" {
+		return aliases, nil
+	}
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return aliases, err
+	}
+	var fileAliases map[string]string
+	if err := yaml.Unmarshal(contents, &fileAliases); err != nil {
+		return aliases, fmt.Errorf("error parsing header aliases file %s: %w", path, err)
+	}
+	for alias, header := range fileAliases {
+		aliases[normalizeHeader(alias)] = header
+	}
+	return aliases, nil
+}
+
+// maxHeaderEdits is the number of edits allowed when matching a misspelled
+// column name, short names must match closely to avoid false matches
+func maxHeaderEdits(header string) int {
+	switch {
+	case len(header) >= 10:
+		return 2
+	case len(header) >= 5:
+		return 1
+	default:
+		return 0
+	}
+}
+
+type headerDictionary struct {
+	names      map[string]bool
+	normalized map[string]string
+}
+
+func newHeaderDictionary(schemas map[string][]MetadataField) headerDictionary {
+	d := headerDictionary{names: map[string]bool{}, normalized: map[string]string{}}
+	for _, fields := range schemas {
+		for _, f := range fields {
+			d.names[f.Name] = true
+			d.normalized[normalizeHeader(f.Name)] = f.Name
+			if f.Key != "" {
+				d.normalized[normalizeHeader(f.Key)] = f.Name
+			}
+		}
+	}
+	return d
+}
+
+// resolve returns the metadata field name for a column and the reason it was
+// matched, the reason is empty if the column should not be renamed
+func (d headerDictionary) resolve(header string, aliases map[string]string, fuzzy bool) (string, string) {
+	normalized := normalizeHeader(header)
+	if to, has := aliases[normalized]; has {
+		if to == header {
+			return header, ""
+		}
+		return to, HeaderMappedByAlias
+	}
+	if d.names[header] || isHostGenomeHeader(header) || isCollectionLocationHeader(header) || sampleNameAliases[header] {
+		return header, ""
+	}
+	if to, has := d.normalized[normalized]; has {
+		return to, HeaderMappedByVariant
+	}
+	if !fuzzy {
+		return header, ""
+	}
+
+	candidates := make([]string, 0, len(d.normalized))
+	for candidate := range d.normalized {
+		candidates = append(candidates, candidate)
+	}
+	sort.Strings(candidates)
+	best, bestDistance, ambiguous := "", maxHeaderEdits(normalized)+1, false
+	for _, candidate := range candidates {
+		distance := util.EditDistance(normalized, candidate)
+		if distance < bestDistance {
+			best, bestDistance, ambiguous = d.normalized[candidate], distance, false
+		} else if distance == bestDistance && d.normalized[candidate] != best {
+			ambiguous = true
+		}
+	}
+	if best == "" || ambiguous {
+		return header, ""
+	}
+	return best, HeaderMappedByFuzzy
+}
+
+// ResolveMetadataHeaders renames metadata columns to the names of the
+// metadata fields in schemas, as returned by GetMetadataSchemas. Columns are
+// matched with the user's aliases, then ignoring case and spacing, then,
+// unless fuzzy matching is disabled, allowing a few misspelled characters.
+// samplesMetadata is updated in place and the mappings applied are returned.
+func ResolveMetadataHeaders(
+	samplesMetadata SamplesMetadata,
+	schemas map[string][]MetadataField,
+	aliases map[string]string,
+	fuzzy bool,
+) []HeaderMapping {
+	d := newHeaderDictionary(schemas)
+
+	headerSet := map[string]bool{}
+	for _, m := range samplesMetadata {
+		for header := range m.fields {
+			headerSet[header] = true
+		}
+	}
+	headers := make([]string, 0, len(headerSet))
+	for header := range headerSet {
+		headers = append(headers, header)
+	}
+	sort.Strings(headers)
+
+	mappings := []HeaderMapping{}
+	for _, header := range headers {
+		to, reason := d.resolve(header, aliases, fuzzy)
+		if reason == "" {
+			continue
+		}
+		mappings = append(mappings, HeaderMapping{From: header, To: to, Reason: reason})
+		for sampleName, m := range samplesMetadata {
+			value, has := m.fields[header]
+			if !has {
+				continue
+			}
+			delete(m.fields, header)
+			// a value already in the correctly named column takes precedence
+			if existing := m.fields[to]; existing != "" && value != existing {
+				fmt.Fprintf(os.Stderr, "warning: sample '%s' has values for both '%s' and '%s', using '%s'\n", sampleName, header, to, existing)
+				continue
+			}
+			samplesMetadata[sampleName] = m.update(map[string]string{to: value})
+		}
+	}
+	return mappings
+}
+
+// ResolveHeaders reads the aliases file in options, renames metadata columns
+// with ResolveMetadataHeaders, and prints each mapping applied to out
+func ResolveHeaders(samplesMetadata SamplesMetadata, schemas map[string][]MetadataField, options HeaderResolverOptions, out io.Writer) error {
+	aliases, err := ReadHeaderAliases(options.AliasesPath)
+	if err != nil {
+		return err
+	}
+	for _, mapping := range ResolveMetadataHeaders(samplesMetadata, schemas, aliases, !options.DisableFuzzy) {
+		fmt.Fprintln(out, mapping)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package czid
+
+import (
+	"os"
+	"testing"
+)
+
+func TestResolveMetadataHeaders(t *testing.T) {
+	samplesMetadata := SamplesMetadata{
+		"sample one": NewMetadata(map[string]string{
+			"Host Organism":     "Human",
+			"collection_date":   "2022-03",
+			"NUCLEOTIDE  TYPE":  "DNA",
+			"Sampel Type":       "Nasopharyngeal Swab",
+			"Ct":                "21.5",
+			"Colection Locaton": "California, USA",
+			"Favorite Color":    "Blue",
+		}),
+		"sample two": NewMetadata(map[string]string{
+			"Host Organism":   "Human",
+			"collection_date": "2022-04",
+			"Collection Date": "2022-05",
+		}),
+	}
+	aliases := map[string]string{"ct": "Ct Value"}
+
+	mappings := ResolveMetadataHeaders(samplesMetadata, testSchemas, aliases, true)
+
+	expected := map[string]HeaderMapping{
+		"collection_date":   {From: "collection_date", To: "Collection Date", Reason: HeaderMappedByVariant},
+		"NUCLEOTIDE  TYPE":  {From: "NUCLEOTIDE  TYPE", To: "Nucleotide Type", Reason: HeaderMappedByVariant},
+		"Sampel Type":       {From: "Sampel Type", To: "Sample Type", Reason: HeaderMappedByFuzzy},
+		"Ct":                {From: "Ct", To: "Ct Value", Reason: HeaderMappedByAlias},
+		"Colection Locaton": {From: "Colection Locaton", To: "Collection Location", Reason: HeaderMappedByFuzzy},
+	}
+	if len(mappings) != len(expected) {
+		t.Errorf("expected %d mappings but got %+v", len(expected), mappings)
+	}
+	for _, mapping := range mappings {
+		if expected[mapping.From] != mapping {
+			t.Errorf("unexpected mapping %+v", mapping)
+		}
+	}
+
+	one := samplesMetadata["sample one"]
+	for header, value := range map[string]string{
+		"Collection Date": "2022-03",
+		"Nucleotide Type": "DNA",
+		"Sample Type":     "Nasopharyngeal Swab",
+		"Ct Value":        "21.5",
+		"Favorite Color":  "Blue",
+	} {
+		if one.fields[header] != value {
+			t.Errorf("expected '%s' to be '%s' but it was '%s'", header, value, one.fields[header])
+		}
+	}
+	if one.rawCollectionLocation != "California, USA" {
+		t.Errorf("expected a misspelled collection location column to set the collection location but it was '%s'", one.rawCollectionLocation)
+	}
+
+	two := samplesMetadata["sample two"]
+	if two.fields["Collection Date"] != "2022-05" {
+		t.Errorf("expected the correctly named column to take precedence but Collection Date was '%s'", two.fields["Collection Date"])
+	}
+	if _, has := two.fields["collection_date"]; has {
+		t.Error("expected collection_date to be removed after it was mapped")
+	}
+}
+
+func TestResolveMetadataHeadersWithoutFuzzy(t *testing.T) {
+	samplesMetadata := SamplesMetadata{
+		"sample one": NewMetadata(map[string]string{"Sampel Type": "Blood", "sample type": "Blood"}),
+	}
+	mappings := ResolveMetadataHeaders(samplesMetadata, testSchemas, map[string]string{}, false)
+	if len(mappings) != 1 || mappings[0].From != "sample type" {
+		t.Errorf("expected only 'sample type' to be mapped but got %+v", mappings)
+	}
+}
+
+func TestReadHeaderAliases(t *testing.T) {
+	f, err := os.CreateTemp("", "*.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	_, err = f.WriteString("Sample_Kind: Sample Type\n\"ct\": Ct Value\n")
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	aliases, err := ReadHeaderAliases(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aliases["sample kind"] != "Sample Type" || aliases["ct"] != "Ct Value" {
+		t.Errorf("unexpected aliases %v", aliases)
+	}
+}
@@ -0,0 +1,124 @@
+package czid
+
+// This file is for matching metadata rows to samples whose names are spelled
+// a little differently, like a metadata row for S-01 and sample files for S01
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// SampleMatchOptions are options for matching metadata rows to samples with
+// slightly different names
+type SampleMatchOptions struct {
+	// AcceptMatches applies every suggested match without asking
+	AcceptMatches bool
+	// MappingPath is an optional CSV file to write the applied matches to
+	MappingPath string
+}
+
+// SuggestSampleNameMatches suggests a sample for each metadata row whose
+// sample name matches no sample files, from the samples no row matches. See
+// MatchSampleNames for how names are matched. Exact matches are left out.
+func SuggestSampleNameMatches(samplesMetadata SamplesMetadata, sampleFiles map[string]SampleFiles) []SampleNameMatch {
+	names := make([]string, 0, len(samplesMetadata))
+	for name := range samplesMetadata {
+		if _, has := sampleFiles[name]; !has {
+			names = append(names, name)
+		}
+	}
+	sampleNames := make([]string, 0, len(sampleFiles))
+	for sampleName := range sampleFiles {
+		if _, has := samplesMetadata[sampleName]; !has {
+			sampleNames = append(sampleNames, sampleName)
+		}
+	}
+	sort.Strings(sampleNames)
+
+	matches, _ := MatchSampleNames(names, sampleNames)
+	return matches
+}
+
+// ResolveSampleNameMatches prints suggested matches and returns the ones to
+// apply. If acceptAll is true every match is applied, otherwise the user is
+// asked about each match, reading from in and writing to out. in should be
+// shared with every other prompt reading the same input.
+func ResolveSampleNameMatches(suggestions []SampleNameMatch, acceptAll bool, in *bufio.Reader, out io.Writer) ([]SampleNameMatch, error) {
+	accepted := []SampleNameMatch{}
+	if len(suggestions) == 0 {
+		return accepted, nil
+	}
+	fmt.Fprintln(out, "found metadata rows whose sample names are close to sample names of sample files:")
+	for _, match := range suggestions {
+		fmt.Fprintf(out, "  %s\n", match)
+	}
+	if acceptAll {
+		return append(accepted, suggestions...), nil
+	}
+
+	for _, match := range suggestions {
+		for {
+			fmt.Fprintf(out, "use the metadata of '%s' for sample '%s'? [Y/n]: ", match.Name, match.Sample)
+			input, err := in.ReadString('\n')
+			input = strings.ToLower(strings.TrimSpace(input))
+			if err != nil && (err != io.EOF || input == "") {
+				return nil, fmt.Errorf("no answer for matching '%s' to sample '%s', apply suggested matches with --accept-matches: %w", match.Name, match.Sample, err)
+			}
+			if input == "" || input == "y" || input == "yes" {
+				accepted = append(accepted, match)
+				break
+			}
+			if input == "n" || input == "no" {
+				break
+			}
+			fmt.Fprintln(out, "please enter y or n")
+			if err == io.EOF {
+				return nil, fmt.Errorf("no answer for matching '%s' to sample '%s'", match.Name, match.Sample)
+			}
+		}
+	}
+	return accepted, nil
+}
+
+// ApplySampleNameMatches keys the metadata of each matched row by its
+// matched sample's name
+func ApplySampleNameMatches(samplesMetadata SamplesMetadata, matches []SampleNameMatch) {
+	matched := make(map[string]Metadata, len(matches))
+	for _, match := range matches {
+		if m, has := samplesMetadata[match.Name]; has {
+			matched[match.Sample] = m
+			delete(samplesMetadata, match.Name)
+		}
+	}
+	for sampleName, m := range matched {
+		samplesMetadata[sampleName] = m
+	}
+}
+
+// WriteSampleNameMatches writes a CSV file mapping the sample names of
+// metadata rows to the sample names they were matched to
+func WriteSampleNameMatches(path string, matches []SampleNameMatch) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	writer := csv.NewWriter(f)
+	err = writer.Write([]string{"Metadata Sample Name", "Sample Name", "Match"})
+	if err != nil {
+		return err
+	}
+	for _, match := range matches {
+		if err := writer.Write([]string{match.Name, match.Sample, match.Reason}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
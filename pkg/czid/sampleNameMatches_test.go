@@ -0,0 +1,116 @@
+package czid
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSuggestSampleNameMatches(t *testing.T) {
+	samplesMetadata := SamplesMetadata{
+		"S-01":     NewMetadata(map[string]string{"Host Organism": "Human"}),
+		"sample_2": NewMetadata(map[string]string{"Host Organism": "Human"}),
+		"other":    NewMetadata(map[string]string{"Host Organism": "Human"}),
+	}
+	sampleFiles := map[string]SampleFiles{
+		"S01":      {},
+		"sample_2": {},
+		"S02":      {},
+	}
+	suggestions := SuggestSampleNameMatches(samplesMetadata, sampleFiles)
+	expected := []SampleNameMatch{{Name: "S-01", Sample: "S01", Reason: SampleNameMatchedNormalized}}
+	if !reflect.DeepEqual(suggestions, expected) {
+		t.Errorf("expected %v, got %v", expected, suggestions)
+	}
+}
+
+func TestResolveSampleNameMatches(t *testing.T) {
+	suggestions := []SampleNameMatch{
+		{Name: "S-01", Sample: "S01", Reason: SampleNameMatchedNormalized},
+		{Name: "S-02", Sample: "S02", Reason: SampleNameMatchedNormalized},
+		{Name: "S-03", Sample: "S03", Reason: SampleNameMatchedNormalized},
+	}
+
+	var out bytes.Buffer
+	matches, err := ResolveSampleNameMatches(suggestions, false, bufio.NewReader(strings.NewReader("\nmaybe\nn\ny\n")), &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []SampleNameMatch{suggestions[0], suggestions[2]}
+	if !reflect.DeepEqual(matches, expected) {
+		t.Errorf("expected %v, got %v", expected, matches)
+	}
+	if !strings.Contains(out.String(), "please enter y or n") {
+		t.Errorf("expected to be asked again after an invalid answer, got %s", out.String())
+	}
+
+	matches, err = ResolveSampleNameMatches(suggestions, true, bufio.NewReader(strings.NewReader("")), &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(matches, suggestions) {
+		t.Errorf("expected every suggestion to be accepted, got %v", matches)
+	}
+
+	_, err = ResolveSampleNameMatches(suggestions, false, bufio.NewReader(strings.NewReader("y\n")), &out)
+	if err == nil || !strings.Contains(err.Error(), "--accept-matches") {
+		t.Errorf("expected an error suggesting --accept-matches, got %v", err)
+	}
+}
+
+func TestGetCombinedMetadataWithSampleNameMatches(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "metadata.csv")
+	contents := "Sample Name,Host Organism\nS-01,Human\nS02,Mosquito\n"
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sampleFiles := map[string]SampleFiles{"S01": {}, "S02": {}}
+
+	if _, err := GetCombinedMetadataWithOptions(sampleFiles, map[string]string{}, path, MetadataReadOptions{}); err == nil {
+		t.Error("expected an error for a sample without metadata")
+	}
+
+	matches := []SampleNameMatch{{Name: "S-01", Sample: "S01", Reason: SampleNameMatchedNormalized}}
+	options := MetadataReadOptions{SampleNameMatches: matches}
+	samplesMetadata, err := GetCombinedMetadataWithOptions(sampleFiles, map[string]string{}, path, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if samplesMetadata["S01"].HostGenome != "Human" || samplesMetadata["S02"].HostGenome != "Mosquito" {
+		t.Errorf("expected matched metadata, got %v", samplesMetadata)
+	}
+	if _, has := samplesMetadata["S-01"]; has {
+		t.Error("expected the matched row to be keyed by its sample")
+	}
+
+	mappingPath := filepath.Join(dir, "matches.csv")
+	if err := WriteSampleNameMatches(mappingPath, matches); err != nil {
+		t.Fatal(err)
+	}
+	mapping, err := os.ReadFile(mappingPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "Metadata Sample Name,Sample Name,Match\nS-01,S01,normalized\n"
+	if string(mapping) != expected {
+		t.Errorf("expected mapping %q, got %q", expected, string(mapping))
+	}
+}
+
+func TestResolveSampleNameMatchesSharedReader(t *testing.T) {
+	suggestions := []SampleNameMatch{{Name: "S-01", Sample: "S01", Reason: SampleNameMatchedNormalized}}
+	in := bufio.NewReader(strings.NewReader("y\n2\n"))
+	if _, err := ResolveSampleNameMatches(suggestions, false, in, &bytes.Buffer{}); err != nil {
+		t.Fatal(err)
+	}
+	// the answer to the next prompt must still be there for it
+	next, err := in.ReadString('\n')
+	if err != nil || next != "2\n" {
+		t.Errorf("expected the next answer to be left unread, got %q, %v", next, err)
+	}
+}
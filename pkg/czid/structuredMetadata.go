@@ -0,0 +1,235 @@
+package czid
+
+// This file is for reading metadata from JSON and YAML files. Files may be an
+// array of sample objects, an object keyed by sample name, or an object with
+// shared "defaults" and the "samples" in either of those layouts:
+//
+//	{
+//	  "defaults": {"Host Organism": "Human"},
+//	  "samples": [{"Sample Name": "sample one", "Nucleotide Type": "DNA"}]
+//	}
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+var sampleNameAliases map[string]bool = map[string]bool{
+	"sample_name": true,
+	"Sample Name": true,
+	"Sample name": true,
+	"sample name": true,
+}
+
+// metadataValueString converts a JSON or YAML scalar to a metadata value
+func metadataValueString(v interface{}) (string, bool) {
+	switch value := v.(type) {
+	case nil:
+		return "", true
+	case string:
+		return trimInvisible(value), true
+	case json.Number:
+		return value.String(), true
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), true
+	case bool, int, int64, uint64:
+		return fmt.Sprint(value), true
+	default:
+		return "", false
+	}
+}
+
+// normalizeYAML converts the map[interface{}]interface{} values produced by
+// yaml.v2 to map[string]interface{} so YAML can be handled like JSON
+func normalizeYAML(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(value))
+		for k, v := range value {
+			m[fmt.Sprint(k)] = normalizeYAML(v)
+		}
+		return m
+	case []interface{}:
+		for i, v := range value {
+			value[i] = normalizeYAML(v)
+		}
+		return value
+	default:
+		return value
+	}
+}
+
+func metadataFields(v interface{}, description string) (map[string]string, error) {
+	object, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s must be an object of metadata names and values", description)
+	}
+	fields := make(map[string]string, len(object))
+	for k, v := range object {
+		value, ok := metadataValueString(v)
+		if !ok {
+			return nil, fmt.Errorf("value of '%s' in %s must be a string, number, or boolean", k, description)
+		}
+		fields[trimInvisible(k)] = value
+	}
+	return fields, nil
+}
+
+func isDefaultsDocument(object map[string]interface{}) bool {
+	if _, hasSamples := object["samples"]; !hasSamples {
+		return false
+	}
+	for k := range object {
+		if k != "samples" && k != "defaults" {
+			return false
+		}
+	}
+	return true
+}
+
+// documentMetadata builds samples' metadata from a decoded JSON or YAML
+// document, merging shared defaults into every sample. Samples in an array
+// are keyed by the field sampleNameHeader chooses for options, samples in an
+// object by their key unless options.SampleNameColumn is set.
+func documentMetadata(document interface{}, options MetadataReadOptions) (SamplesMetadata, error) {
+	samplesMetadata := SamplesMetadata{}
+	defaults := map[string]string{}
+	samples := document
+
+	if object, ok := document.(map[string]interface{}); ok && isDefaultsDocument(object) {
+		samples = object["samples"]
+		if d, has := object["defaults"]; has && d != nil {
+			var err error
+			defaults, err = metadataFields(d, "defaults")
+			if err != nil {
+				return samplesMetadata, err
+			}
+		}
+	}
+
+	addSample := func(sampleName string, fields map[string]string) error {
+		if sampleName == "" {
+			return errors.New("metadata is missing 'Sample Name' for a sample")
+		}
+		if _, has := samplesMetadata[sampleName]; has {
+			return fmt.Errorf("found metadata for sample '%s' more than once", sampleName)
+		}
+		// defaults are applied first so the sample's own values override them
+		samplesMetadata[sampleName] = NewMetadata(defaults).update(fields)
+		return nil
+	}
+
+	switch s := samples.(type) {
+	case []interface{}:
+		for i, sample := range s {
+			fields, err := metadataFields(sample, fmt.Sprintf("sample %d", i+1))
+			if err != nil {
+				return samplesMetadata, err
+			}
+			headers := make([]string, 0, len(fields))
+			for k := range fields {
+				headers = append(headers, k)
+			}
+			sort.Strings(headers)
+			keyHeader, err := sampleNameHeader(headers, options.SampleNameColumn, options.KeyBySampleID)
+			if err != nil {
+				return samplesMetadata, fmt.Errorf("sample %d: %w", i+1, err)
+			}
+			sampleName := fields[keyHeader]
+			// rows keyed by sample ID are matched to samples by their
+			// "Sample ID", like rows of delimited files
+			if keyHeader != "Sample ID" {
+				delete(fields, keyHeader)
+			}
+			for k := range fields {
+				if sampleNameAliases[k] {
+					delete(fields, k)
+				}
+			}
+			if err := addSample(sampleName, fields); err != nil {
+				return samplesMetadata, err
+			}
+		}
+	case map[string]interface{}:
+		sampleNames := make([]string, 0, len(s))
+		for sampleName := range s {
+			sampleNames = append(sampleNames, sampleName)
+		}
+		sort.Strings(sampleNames)
+		for _, sampleName := range sampleNames {
+			fields, err := metadataFields(s[sampleName], fmt.Sprintf("sample '%s'", sampleName))
+			if err != nil {
+				return samplesMetadata, err
+			}
+			key := trimInvisible(sampleName)
+			for k, v := range fields {
+				if options.SampleNameColumn != "" && normalizeHeader(k) == normalizeHeader(options.SampleNameColumn) {
+					key = v
+					delete(fields, k)
+				}
+				if sampleNameAliases[k] {
+					delete(fields, k)
+				}
+			}
+			if err := addSample(key, fields); err != nil {
+				return samplesMetadata, err
+			}
+		}
+	default:
+		return samplesMetadata, errors.New("metadata must be an array of samples or an object keyed by sample name")
+	}
+	return samplesMetadata, nil
+}
+
+// readMetadataText reads a JSON or YAML metadata file as UTF-8, byte order
+// marks and UTF-16 are detected
+func readMetadataText(path string) (string, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return decodeMetadataText(contents, MetadataEncodingAuto)
+}
+
+// JSONMetadata reads samples' metadata from a JSON file
+func JSONMetadata(path string) (SamplesMetadata, error) {
+	return jsonMetadata(path, MetadataReadOptions{})
+}
+
+func jsonMetadata(path string, options MetadataReadOptions) (SamplesMetadata, error) {
+	contents, err := readMetadataText(path)
+	if err != nil {
+		return SamplesMetadata{}, err
+	}
+	decoder := json.NewDecoder(strings.NewReader(contents))
+	decoder.UseNumber()
+	var document interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return SamplesMetadata{}, fmt.Errorf("error parsing %s: %w", path, err)
+	}
+	return documentMetadata(document, options)
+}
+
+// YAMLMetadata reads samples' metadata from a YAML file
+func YAMLMetadata(path string) (SamplesMetadata, error) {
+	return yamlMetadata(path, MetadataReadOptions{})
+}
+
+func yamlMetadata(path string, options MetadataReadOptions) (SamplesMetadata, error) {
+	contents, err := readMetadataText(path)
+	if err != nil {
+		return SamplesMetadata{}, err
+	}
+	var document interface{}
+	if err := yaml.Unmarshal([]byte(contents), &document); err != nil {
+		return SamplesMetadata{}, fmt.Errorf("error parsing %s: %s", path, strings.TrimPrefix(err.Error(), "yaml: "))
+	}
+	return documentMetadata(normalizeYAML(document), options)
+}
@@ -0,0 +1,130 @@
+package czid
+
+import (
+	"os"
+	"testing"
+)
+
+func writeTempMetadata(t *testing.T, pattern string, contents string) string {
+	f, err := os.CreateTemp("", pattern)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(contents); err != nil {
+		t.Fatal(err)
+	}
+	return f.Name()
+}
+
+func checkStructuredMetadata(t *testing.T, samplesMetadata SamplesMetadata) {
+	if len(samplesMetadata) != 2 {
+		t.Fatalf("expected 2 samples but found %d", len(samplesMetadata))
+	}
+	one := samplesMetadata["sample one"]
+	if !one.isHuman() {
+		t.Errorf("expected sample one to get host organism Human from the defaults but it was '%s'", one.HostGenome)
+	}
+	if one.rawCollectionLocation != "California, USA" {
+		t.Errorf("expected rawCollectionLocation to be \"California, USA\" but it was \"%s\"", one.rawCollectionLocation)
+	}
+	if one.fields["Age"] != "42.5" {
+		t.Errorf("expected Age to be \"42.5\" but it was \"%s\"", one.fields["Age"])
+	}
+	two := samplesMetadata["sample two"]
+	if two.HostGenome != "Dog" {
+		t.Errorf("expected sample two to override the default host organism with Dog but it was '%s'", two.HostGenome)
+	}
+	if two.fields["Nucleotide Type"] != "RNA" {
+		t.Errorf("expected sample two Nucleotide Type to be RNA but it was '%s'", two.fields["Nucleotide Type"])
+	}
+	if _, has := two.fields["Sample Name"]; has {
+		t.Error("expected Sample Name to not be included in metadata fields")
+	}
+}
+
+func TestJSONMetadataArray(t *testing.T) {
+	path := writeTempMetadata(t, "*.json", `{
+  "defaults": {"Host Organism": "Human", "Nucleotide Type": "DNA"},
+  "samples": [
+    {"Sample Name": "sample one", "collection_location": "California, USA", "Age": 42.5},
+    {"sample_name": "sample two", "host_genome": "Dog", "Nucleotide Type": "RNA"}
+  ]
+}`)
+	defer os.Remove(path)
+
+	samplesMetadata, err := ReadMetadataFile(path, MetadataReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkStructuredMetadata(t, samplesMetadata)
+}
+
+func TestYAMLMetadataKeyedBySampleName(t *testing.T) {
+	path := writeTempMetadata(t, "*.yaml", `defaults:
+  Host Organism: Human
+  Nucleotide Type: DNA
+samples:
+  sample one:
+    Collection Location: California, USA
+    Age: 42.5
+  sample two:
+    Host Organism: Dog
+    Nucleotide Type: RNA
+`)
+	defer os.Remove(path)
+
+	samplesMetadata, err := ReadMetadataFile(path, MetadataReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkStructuredMetadata(t, samplesMetadata)
+}
+
+func TestStructuredMetadataReadOptions(t *testing.T) {
+	path := writeTempMetadata(t, "*.json", `[{"Sample ID": 12, "Host Organism": "Human"}]`)
+	defer os.Remove(path)
+	if _, err := ReadMetadataFile(path, MetadataReadOptions{}); err == nil {
+		t.Error("expected an error for samples without a sample name")
+	}
+	samplesMetadata, err := ReadMetadataFile(path, MetadataReadOptions{KeyBySampleID: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if samplesMetadata["12"].fields["Sample ID"] != "12" {
+		t.Errorf("expected the sample to be keyed by its sample ID but got %v", samplesMetadata)
+	}
+
+	path = writeTempMetadata(t, "*.yaml", `- Lab ID: sample one
+  Sample Name: ignored
+  Dilution: 1000000.0
+`)
+	defer os.Remove(path)
+	samplesMetadata, err = ReadMetadataFile(path, MetadataReadOptions{SampleNameColumn: "lab_id"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	one, has := samplesMetadata["sample one"]
+	if !has {
+		t.Fatalf("expected the sample to be keyed by its Lab ID but got %v", samplesMetadata)
+	}
+	if one.fields["Dilution"] != "1000000" {
+		t.Errorf("expected Dilution to be \"1000000\" but it was \"%s\"", one.fields["Dilution"])
+	}
+}
+
+func TestJSONMetadataErrors(t *testing.T) {
+	documents := map[string]string{
+		"missing sample name": `[{"Host Organism": "Human"}]`,
+		"nested value":        `{"sample one": {"Host Organism": {"name": "Human"}}}`,
+		"duplicate sample":    `[{"Sample Name": "a"}, {"Sample Name": "a"}]`,
+		"not samples":         `"sample one"`,
+	}
+	for description, document := range documents {
+		path := writeTempMetadata(t, "*.json", document)
+		if _, err := JSONMetadata(path); err == nil {
+			t.Errorf("%s: expected an error", description)
+		}
+		os.Remove(path)
+	}
+}
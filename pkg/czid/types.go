@@ -9,3 +9,30 @@ type SampleOptions struct {
 	ReferenceFasta     string
 	PrimerBed          string
 }
+
+// UploadFlowOptions control the checks UploadSamplesFlow performs before
+// creating samples
+type UploadFlowOptions struct {
+	AllowDuplicateContent bool
+	FullHash              bool
+	// NameConflictPolicy is one of NameConflictPolicies
+	NameConflictPolicy string
+	// NameMappingPath is an optional CSV file to write original sample names,
+	// final sample names, and sample IDs to
+	NameMappingPath string
+	// SkipLocalValidation skips checking metadata against the cached metadata
+	// fields of each host organism before validating it with CZ ID
+	SkipLocalValidation   bool
+	MetadataReadOptions   MetadataReadOptions
+	HeaderResolverOptions HeaderResolverOptions
+	LocationOptions       LocationOptions
+	DateOptions           DateOptions
+	// ValidationReportPath is an optional JSON or CSV file to write every
+	// metadata validation issue to
+	ValidationReportPath string
+	// StrictHosts refuses to create samples whose host organism is not
+	// supported by CZ ID instead of warning about them
+	StrictHosts        bool
+	PHIOptions         PHIOptions
+	SampleMatchOptions SampleMatchOptions
+}
@@ -85,7 +85,14 @@ func (c *Client) CreateSamples(
 	for sampleName := range samplesMetadata {
 		files := sampleFiles[sampleName]
 		var filesMetadata []inputFileMetadata
-		if len(files.Single) > 0 {
+		if len(files.BAM) > 0 {
+			for _, name := range BAMFASTQNames(files) {
+				filesMetadata = append(filesMetadata, inputFileMetadata{
+					Filename: name,
+					FileType: FASTQFileType,
+				})
+			}
+		} else if len(files.Single) > 0 {
 			metadata := inputFileMetadata {
 				Filename: StripLaneNumber(files.Single[0]),
 				FileType: FASTQFileType,
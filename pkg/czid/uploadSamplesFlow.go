@@ -1,6 +1,8 @@
 package czid
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -9,7 +11,9 @@ import (
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 
+	"github.com/chanzuckerberg/czid-cli/pkg/bam"
 	"github.com/chanzuckerberg/czid-cli/pkg/upload"
+	"github.com/chanzuckerberg/czid-cli/pkg/util"
 )
 
 func UploadSamplesFlow(
@@ -20,16 +24,104 @@ func UploadSamplesFlow(
 	workflow string,
 	sampleOptions SampleOptions,
 	disableBuffer bool,
+	flowOptions UploadFlowOptions,
 ) error {
+	if !util.StringSliceContains(NameConflictPolicies, flowOptions.NameConflictPolicy) {
+		return fmt.Errorf("on-name-conflict \"%s\" not supported, please choose one of: %s", flowOptions.NameConflictPolicy, strings.Join(NameConflictPolicies, ", "))
+	}
+
+	if flowOptions.ValidationReportPath != "" {
+		if err := CheckValidationReportPath(flowOptions.ValidationReportPath); err != nil {
+			return err
+		}
+	}
+	if flowOptions.DateOptions.Order != "" && !util.StringSliceContains(DateOrders, flowOptions.DateOptions.Order) {
+		return fmt.Errorf("date-order \"%s\" not supported, please choose one of: %s", flowOptions.DateOptions.Order, strings.Join(DateOrders, ", "))
+	}
+	if err := CheckMetadataReadOptions(flowOptions.MetadataReadOptions); err != nil {
+		return err
+	}
+	if flowOptions.LocationOptions.Mode != "" && !util.StringSliceContains(LocationModes, flowOptions.LocationOptions.Mode) {
+		return fmt.Errorf("location-mode \"%s\" not supported, please choose one of: %s", flowOptions.LocationOptions.Mode, strings.Join(LocationModes, ", "))
+	}
+
+	err := CheckDuplicateContent(sampleFiles, flowOptions.FullHash, flowOptions.AllowDuplicateContent)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	projectID, err := DefaultClient.GetProjectID(projectName)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	samplesMetadata, err := GetCombinedMetadata(sampleFiles, stringMetadata, metadataCSVPath)
+	// every prompt reads answers from the same reader so none of them reads
+	// ahead into another's answers
+	stdin := bufio.NewReader(os.Stdin)
+
+	reportIssues := []MetadataIssue{}
+	if metadataCSVPath != "" {
+		matches := resolveSampleNameMatches(sampleFiles, metadataCSVPath, flowOptions, stdin)
+		flowOptions.MetadataReadOptions.SampleNameMatches = matches
+
+		// matched rows are linted as if they had the sample names of the
+		// samples they were matched to
+		sampleNames := make([]string, 0, len(sampleFiles)+len(matches))
+		for sampleName := range sampleFiles {
+			sampleNames = append(sampleNames, sampleName)
+		}
+		for _, match := range matches {
+			sampleNames = append(sampleNames, match.Name)
+		}
+		issues, err := LintMetadataFile(metadataCSVPath, flowOptions.MetadataReadOptions, sampleNames)
+		if err != nil {
+			log.Fatal(err)
+		}
+		reportIssues = append(reportIssues, issues...)
+		if errorCount, _ := CountIssues(issues); errorCount > 0 {
+			PrintMetadataIssues(os.Stdout, reportIssues)
+			writeValidationReport(flowOptions.ValidationReportPath, reportIssues)
+			os.Exit(ExitCodeValidationErrors)
+		}
+	}
+
+	flowOptions.MetadataReadOptions.Rules = flowOptions.MetadataReadOptions.Rules.WithConfig()
+	samplesMetadata, err := GetCombinedMetadataWithOptions(sampleFiles, stringMetadata, metadataCSVPath, flowOptions.MetadataReadOptions)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	schemas, err := DefaultClient.GetMetadataSchemas(samplesMetadata, false)
+	if err != nil {
+		if !flowOptions.SkipLocalValidation {
+			log.Fatal(err)
+		}
+		fmt.Printf("warning: metadata columns will only be matched with aliases: %s\n", err)
+	}
+	err = ResolveHeaders(samplesMetadata, schemas, flowOptions.HeaderResolverOptions, os.Stdout)
 	if err != nil {
 		log.Fatal(err)
 	}
+	PrintDateChanges(os.Stdout, NormalizeDates(samplesMetadata, schemas, flowOptions.DateOptions))
+
+	hostGenomes, err := DefaultClient.GetHostGenomes(false)
+	if err != nil {
+		if flowOptions.StrictHosts {
+			log.Fatal(err)
+		}
+		fmt.Printf("warning: host organisms will not be checked: %s\n", err)
+	} else {
+		reportIssues = append(reportIssues, CheckHostGenomes(samplesMetadata, hostGenomes, flowOptions.StrictHosts)...)
+	}
+	if !flowOptions.SkipLocalValidation {
+		reportIssues = append(reportIssues, ValidateMetadataLocally(samplesMetadata, schemas)...)
+	}
+	reportIssues = append(reportIssues, CheckPHI(samplesMetadata, flowOptions.PHIOptions)...)
+	PrintMetadataIssues(os.Stdout, reportIssues)
+	if errorCount, _ := CountIssues(reportIssues); errorCount > 0 {
+		writeValidationReport(flowOptions.ValidationReportPath, reportIssues)
+		os.Exit(ExitCodeValidationErrors)
+	}
 
 	sampleNames := make([]string, 0, len(sampleFiles))
 	for sampleName := range samplesMetadata {
@@ -39,26 +131,36 @@ func UploadSamplesFlow(
 	if err != nil {
 		log.Fatal(err)
 	}
-	if len(sampleNames) != len(newSampleNames) {
-		log.Fatal("error validating sample names")
+	namePlan, err := PlanSampleNames(sampleNames, newSampleNames, flowOptions.NameConflictPolicy)
+	if err != nil {
+		log.Fatal(err)
 	}
-	for i := range sampleNames {
-		if newSampleNames[i] != sampleNames[i] {
-			samplesMetadata[newSampleNames[i]] = samplesMetadata[sampleNames[i]]
-			delete(samplesMetadata, sampleNames[i])
-			sampleFiles[newSampleNames[i]] = sampleFiles[sampleNames[i]]
-			delete(sampleFiles, sampleNames[i])
-		}
+	namePlan.friendlyPrint()
+	for _, sampleName := range namePlan.Skipped {
+		delete(samplesMetadata, sampleName)
+		delete(sampleFiles, sampleName)
+	}
+	for originalName, newName := range namePlan.Renamed {
+		samplesMetadata[newName] = samplesMetadata[originalName]
+		delete(samplesMetadata, originalName)
+		sampleFiles[newName] = sampleFiles[originalName]
+		delete(sampleFiles, originalName)
+	}
+	if len(samplesMetadata) == 0 {
+		fmt.Println("no samples left to upload")
+		return nil
 	}
 
-	err = GeoSearchSuggestions(&samplesMetadata)
+	err = DefaultClient.ResolveCollectionLocations(samplesMetadata, flowOptions.LocationOptions, stdin, os.Stdout)
 	if err != nil {
 		log.Fatal(err)
 	}
-	err = DefaultClient.ValidateSamplesMetadata(projectID, samplesMetadata)
+	issues, err := DefaultClient.ValidateSamplesMetadataIssues(projectID, samplesMetadata)
+	reportIssues = append(reportIssues, issues...)
+	writeValidationReport(flowOptions.ValidationReportPath, reportIssues)
 	if err != nil {
-		if err.Error() == "metadata validation failed" {
-			os.Exit(1)
+		if errors.Is(err, ErrMetadataValidationFailed) {
+			os.Exit(ExitCodeValidationErrors)
 		}
 		log.Fatal(err)
 	}
@@ -74,6 +176,17 @@ func UploadSamplesFlow(
 		log.Fatal(err)
 	}
 
+	if flowOptions.NameMappingPath != "" {
+		sampleIDs := make(map[string]int, len(samples))
+		for _, sample := range samples {
+			sampleIDs[sample.Name] = sample.ID
+		}
+		err = WriteNameMapping(flowOptions.NameMappingPath, namePlan, sampleNames, sampleIDs)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	var credentials aws.Credentials
 	for _, sample := range samples {
 		credentials, err = DefaultClient.GetUploadCredentials(sample.ID)
@@ -83,6 +196,14 @@ func UploadSamplesFlow(
 		u := upload.NewUploader(credentials, disableBuffer)
 		sF := sampleFiles[sample.Name]
 		for _, inputFile := range sample.InputFiles {
+			if len(sF.BAM) > 0 {
+				err := uploadBAM(&u, sF, inputFile)
+				if err != nil {
+					log.Fatal(err)
+				}
+				continue
+			}
+
 			var filenames []string
 			if len(sF.R1) > 0 && filepath.Base(StripLaneNumber(sF.R1[0])) == filepath.Base(inputFile.S3Path) {
 				filenames = sF.R1
@@ -124,5 +245,89 @@ func UploadSamplesFlow(
 			log.Fatal(err)
 		}
 	}
+
+	if ValidationExitCode(reportIssues) == ExitCodeValidationWarnings {
+		if flowOptions.ValidationReportPath != "" {
+			fmt.Printf("uploaded with metadata warnings, see %s\n", flowOptions.ValidationReportPath)
+		} else {
+			fmt.Println("uploaded with metadata warnings")
+		}
+		os.Exit(ExitCodeValidationWarnings)
+	}
 	return nil
 }
+
+// resolveSampleNameMatches suggests samples for metadata rows whose sample
+// names match no sample files and returns the matches to apply, writing them
+// to the sample match mapping if a path was given. If the metadata file can't
+// be read no matches are suggested, linting it reports why.
+func resolveSampleNameMatches(sampleFiles map[string]SampleFiles, metadataPath string, flowOptions UploadFlowOptions, stdin *bufio.Reader) []SampleNameMatch {
+	samplesMetadata, err := ReadMetadataFile(metadataPath, flowOptions.MetadataReadOptions)
+	if err != nil {
+		return []SampleNameMatch{}
+	}
+	suggestions := SuggestSampleNameMatches(samplesMetadata, sampleFiles)
+	matches, err := ResolveSampleNameMatches(suggestions, flowOptions.SampleMatchOptions.AcceptMatches, stdin, os.Stdout)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if flowOptions.SampleMatchOptions.MappingPath != "" {
+		if err := WriteSampleNameMatches(flowOptions.SampleMatchOptions.MappingPath, matches); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("wrote sample name matches to %s\n", flowOptions.SampleMatchOptions.MappingPath)
+	}
+	return matches
+}
+
+// writeValidationReport writes a validation report if a path was given, a
+// report that can't be written is fatal
+func writeValidationReport(path string, issues []MetadataIssue) {
+	if path == "" {
+		return
+	}
+	if err := WriteValidationReport(path, issues); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("wrote validation report to %s\n", path)
+}
+
+// bamFASTQSizeFactor is how many times larger than its BAM files the gzipped
+// FASTQ of a sample is assumed to be. Gzipped FASTQ is usually one and a half
+// to two times the size of the BAM, the part size of the upload is chosen from
+// this estimate so overestimating keeps large uploads within the maximum
+// number of parts.
+const bamFASTQSizeFactor = 4
+
+// uploadBAM streams the reads of a sample's BAM files that belong in
+// inputFile as gzipped FASTQ
+func uploadBAM(u *upload.Uploader, sF SampleFiles, inputFile UploadInfo) error {
+	names := BAMFASTQNames(sF)
+	mate := bam.MateSingle
+	if sF.BAMPaired {
+		switch filepath.Base(inputFile.S3Path) {
+		case names[0]:
+			mate = bam.Mate1
+		case names[1]:
+			mate = bam.Mate2
+		default:
+			return fmt.Errorf("s3 path %s did not match any of %s", inputFile.S3Path, strings.Join(names, ", "))
+		}
+	} else if filepath.Base(inputFile.S3Path) != names[0] {
+		return fmt.Errorf("s3 path %s did not match %s", inputFile.S3Path, names[0])
+	}
+
+	size := int64(0)
+	for _, path := range sF.BAM {
+		stat, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		size += stat.Size() * bamFASTQSizeFactor
+	}
+
+	reader := bam.GzippedFASTQ(sF.BAM, sF.CRAMReference, mate)
+	defer reader.Close()
+	description := fmt.Sprintf("%s (converted from %s)", filepath.Base(inputFile.S3Path), strings.Join(sF.BAM, ", "))
+	return u.UploadStream(reader, size, description, inputFile.S3Path, inputFile.MultipartUploadId)
+}
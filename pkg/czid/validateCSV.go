@@ -120,6 +120,13 @@ type validateCSVRes struct {
 }
 
 func (c *Client) ValidateSamplesMetadata(projectID int, samplesMetadata SamplesMetadata) error {
+	_, err := c.ValidateSamplesMetadataIssues(projectID, samplesMetadata)
+	return err
+}
+
+// ValidateSamplesMetadataIssues validates metadata with CZ ID like
+// ValidateSamplesMetadata and also returns every error and warning found
+func (c *Client) ValidateSamplesMetadataIssues(projectID int, samplesMetadata SamplesMetadata) ([]MetadataIssue, error) {
 	req := validateCSVReq{}
 	for sampleName := range samplesMetadata {
 		req.Samples = append(req.Samples, validateCSVReqSample{
@@ -155,7 +162,7 @@ func (c *Client) ValidateSamplesMetadata(projectID int, samplesMetadata SamplesM
 	var res validateCSVRes
 	err := c.request("POST", "/metadata/validate_csv_for_new_samples.json", "", req, &res)
 	if err != nil {
-		return err
+		return []MetadataIssue{}, err
 	}
 
 	res.Issues.friendlyPrint()
@@ -180,8 +187,9 @@ confirm these host organisms are correct:`)
 		}
 	}
 
+	issues := res.Issues.metadataIssues()
 	if len(res.Issues.Errors) > 0 {
-		return errors.New("metadata validation failed")
+		return issues, ErrMetadataValidationFailed
 	}
-	return nil
+	return issues, nil
 }
@@ -0,0 +1,280 @@
+package czid
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// severities of metadata issues
+const (
+	IssueError   = "error"
+	IssueWarning = "warning"
+)
+
+// MetadataIssue is a single problem with a sample's metadata
+type MetadataIssue struct {
+	Severity   string `json:"severity"`
+	Caption    string `json:"caption"`
+	SampleName string `json:"sample_name"`
+	Column     string `json:"column"`
+	Value      string `json:"value"`
+}
+
+// metadataDateLayouts are the date formats accepted for date fields
+var metadataDateLayouts = []string{
+	"2006-01-02",
+	"2006-01",
+	"2006/01/02",
+	"2006/01",
+	"01/02/2006",
+	"1/2/2006",
+	"01/2006",
+	"1/2006",
+}
+
+// normalizeHeader lower cases a metadata column name and treats underscores
+// and runs of whitespace as single spaces
+func normalizeHeader(header string) string {
+	return strings.Join(strings.Fields(strings.ToLower(strings.ReplaceAll(header, "_", " "))), " ")
+}
+
+func isMetadataDate(value string) bool {
+	for _, layout := range metadataDateLayouts {
+		if _, err := time.Parse(layout, value); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func isHostGenomeHeader(header string) bool {
+	for alias := range hostGenomeAliases {
+		if normalizeHeader(alias) == normalizeHeader(header) {
+			return true
+		}
+	}
+	return false
+}
+
+func isCollectionLocationHeader(header string) bool {
+	for alias := range collectionLocationAliases {
+		if normalizeHeader(alias) == normalizeHeader(header) {
+			return true
+		}
+	}
+	return normalizeHeader(header) == "collection location v2"
+}
+
+func checkFieldValue(field MetadataField, sampleName string, column string, value string) []MetadataIssue {
+	issues := []MetadataIssue{}
+	if value == "" {
+		return issues
+	}
+
+	if len(field.Example.All) > 0 {
+		allowed := false
+		for _, option := range field.Example.All {
+			if strings.EqualFold(option, value) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			issues = append(issues, MetadataIssue{
+				Severity:   IssueError,
+				Caption:    fmt.Sprintf("value is not one of the options for %s: %s", field.Name, strings.Join(field.Example.All, ", ")),
+				SampleName: sampleName,
+				Column:     column,
+				Value:      value,
+			})
+		}
+	}
+
+	switch field.DataType {
+	case "number":
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			issues = append(issues, MetadataIssue{
+				Severity:   IssueError,
+				Caption:    fmt.Sprintf("%s must be a number", field.Name),
+				SampleName: sampleName,
+				Column:     column,
+				Value:      value,
+			})
+		}
+	case "date":
+		if !isMetadataDate(value) {
+			issues = append(issues, MetadataIssue{
+				Severity:   IssueError,
+				Caption:    fmt.Sprintf("%s must be a date formatted as YYYY-MM-DD or YYYY-MM", field.Name),
+				SampleName: sampleName,
+				Column:     column,
+				Value:      value,
+			})
+		}
+	}
+	return issues
+}
+
+// ValidateMetadataLocally checks samples' metadata against the metadata
+// fields of their host organisms. schemas is keyed by lower case host
+// organism name, as returned by GetMetadataSchemas. It checks required fields,
+// option values, number and date formats, and reports unknown columns as
+// warnings.
+func ValidateMetadataLocally(samplesMetadata SamplesMetadata, schemas map[string][]MetadataField) []MetadataIssue {
+	return validateMetadataLocally(samplesMetadata, schemas, true)
+}
+
+// ValidateMetadataUpdateLocally is ValidateMetadataLocally for updates to
+// uploaded samples, only the columns being updated are checked so missing
+// required fields are not reported
+func ValidateMetadataUpdateLocally(samplesMetadata SamplesMetadata, schemas map[string][]MetadataField) []MetadataIssue {
+	return validateMetadataLocally(samplesMetadata, schemas, false)
+}
+
+func validateMetadataLocally(samplesMetadata SamplesMetadata, schemas map[string][]MetadataField, checkRequired bool) []MetadataIssue {
+	issues := []MetadataIssue{}
+
+	sampleNames := make([]string, 0, len(samplesMetadata))
+	for sampleName := range samplesMetadata {
+		sampleNames = append(sampleNames, sampleName)
+	}
+	sort.Strings(sampleNames)
+
+	unknownColumns := map[string]bool{}
+	for _, sampleName := range sampleNames {
+		m := samplesMetadata[sampleName]
+		if m.HostGenome == "" {
+			issues = append(issues, MetadataIssue{
+				Severity:   IssueError,
+				Caption:    "Host Organism is required",
+				SampleName: sampleName,
+				Column:     "Host Organism",
+			})
+			continue
+		}
+
+		fields, has := schemas[strings.ToLower(m.HostGenome)]
+		if !has || len(fields) == 0 {
+			issues = append(issues, MetadataIssue{
+				Severity:   IssueWarning,
+				Caption:    "no metadata fields found for host organism, metadata was not validated locally",
+				SampleName: sampleName,
+				Column:     "Host Organism",
+				Value:      m.HostGenome,
+			})
+			continue
+		}
+
+		fieldsByHeader := make(map[string]MetadataField, 2*len(fields))
+		for _, f := range fields {
+			fieldsByHeader[normalizeHeader(f.Name)] = f
+			if f.Key != "" {
+				fieldsByHeader[normalizeHeader(f.Key)] = f
+			}
+		}
+
+		columns := make([]string, 0, len(m.fields))
+		for column := range m.fields {
+			columns = append(columns, column)
+		}
+		sort.Strings(columns)
+
+		present := map[string]bool{}
+		for _, column := range columns {
+			if isHostGenomeHeader(column) {
+				continue
+			}
+			field, known := fieldsByHeader[normalizeHeader(column)]
+			if !known {
+				unknownColumns[column] = true
+				continue
+			}
+			value := m.fields[column]
+			if value != "" {
+				present[field.Name] = true
+			}
+			issues = append(issues, checkFieldValue(field, sampleName, column, value)...)
+		}
+
+		for _, f := range fields {
+			if !checkRequired || !f.IsRequired || present[f.Name] || isHostGenomeHeader(f.Name) {
+				continue
+			}
+			if isCollectionLocationHeader(f.Name) || isCollectionLocationHeader(f.Key) {
+				if m.rawCollectionLocation != "" || m.CollectionLocation != (GeoSearchSuggestion{}) {
+					continue
+				}
+			}
+			issues = append(issues, MetadataIssue{
+				Severity:   IssueError,
+				Caption:    fmt.Sprintf("%s is required", f.Name),
+				SampleName: sampleName,
+				Column:     f.Name,
+			})
+		}
+	}
+
+	unknown := make([]string, 0, len(unknownColumns))
+	for column := range unknownColumns {
+		unknown = append(unknown, column)
+	}
+	sort.Strings(unknown)
+	for _, column := range unknown {
+		issues = append(issues, MetadataIssue{
+			Severity: IssueWarning,
+			Caption:  "column is not a known metadata field for these host organisms",
+			Column:   column,
+		})
+	}
+	return issues
+}
+
+// CountIssues returns the number of errors and warnings in issues
+func CountIssues(issues []MetadataIssue) (int, int) {
+	errorCount, warningCount := 0, 0
+	for _, issue := range issues {
+		if issue.Severity == IssueError {
+			errorCount++
+		} else {
+			warningCount++
+		}
+	}
+	return errorCount, warningCount
+}
+
+// PrintMetadataIssues prints metadata issues to w in the same layout as the
+// issues returned by CZ ID's metadata validation
+func PrintMetadataIssues(w io.Writer, issues []MetadataIssue) {
+	errorCount, warningCount := CountIssues(issues)
+	if errorCount == 0 && warningCount == 0 {
+		return
+	}
+	fmt.Fprintf(w, "found %d errors and %d warnings\n\n", errorCount, warningCount)
+	for _, severity := range []string{IssueError, IssueWarning} {
+		printedHeader := false
+		for _, issue := range issues {
+			if issue.Severity != severity {
+				continue
+			}
+			if !printedHeader {
+				fmt.Fprintf(w, "%ss:\n", severity)
+				printedHeader = true
+			}
+			fmt.Fprintf(w, "  %s\n", issue.Caption)
+			if issue.SampleName != "" {
+				fmt.Fprintf(w, "      Sample Name: %s\n", issue.SampleName)
+			}
+			if issue.Column != "" {
+				fmt.Fprintf(w, "      Column: %s\n", issue.Column)
+			}
+			if issue.Value != "" {
+				fmt.Fprintf(w, "      Value: %s\n", issue.Value)
+			}
+			fmt.Fprintln(w, "")
+		}
+	}
+}
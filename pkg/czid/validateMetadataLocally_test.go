@@ -0,0 +1,67 @@
+package czid
+
+import (
+	"testing"
+)
+
+var testSchemas = map[string][]MetadataField{
+	"human": {
+		{Key: "host_genome", Name: "Host Organism", IsRequired: true},
+		{Key: "sample_type", Name: "Sample Type", IsRequired: true},
+		{Key: "nucleotide_type", Name: "Nucleotide Type", IsRequired: true, Example: Example{All: []string{"DNA", "RNA"}}},
+		{Key: "collection_date", Name: "Collection Date", IsRequired: true, DataType: "date"},
+		{Key: "collection_location_v2", Name: "Collection Location", IsRequired: true, DataType: "location"},
+		{Key: "ct_value", Name: "Ct Value", DataType: "number"},
+	},
+}
+
+func TestValidateMetadataLocally(t *testing.T) {
+	samplesMetadata := SamplesMetadata{
+		"valid": NewMetadata(map[string]string{
+			"Host Organism":       "Human",
+			"sample_type":         "Nasopharyngeal Swab",
+			"Nucleotide Type":     "dna",
+			"Collection Date":     "2022-03",
+			"Collection Location": "California, USA",
+			"Ct Value":            "21.5",
+		}),
+		"invalid": NewMetadata(map[string]string{
+			"Host Organism":   "Human",
+			"Nucleotide Type": "Protein",
+			"Collection Date": "March",
+			"Ct Value":        "high",
+			"Favorite Color":  "Blue",
+		}),
+		"no host": NewMetadata(map[string]string{}),
+	}
+
+	issues := ValidateMetadataLocally(samplesMetadata, testSchemas)
+
+	captions := map[string]MetadataIssue{}
+	for _, issue := range issues {
+		if issue.SampleName == "valid" {
+			t.Errorf("expected no issues for sample 'valid' but got: %+v", issue)
+		}
+		captions[issue.SampleName+": "+issue.Caption] = issue
+	}
+
+	expected := []string{
+		"invalid: value is not one of the options for Nucleotide Type: DNA, RNA",
+		"invalid: Collection Date must be a date formatted as YYYY-MM-DD or YYYY-MM",
+		"invalid: Ct Value must be a number",
+		"invalid: Sample Type is required",
+		"invalid: Collection Location is required",
+		"no host: Host Organism is required",
+		": column is not a known metadata field for these host organisms",
+	}
+	for _, caption := range expected {
+		if _, has := captions[caption]; !has {
+			t.Errorf("expected issue '%s' but it was not found in %+v", caption, issues)
+		}
+	}
+
+	errorCount, warningCount := CountIssues(issues)
+	if errorCount != 6 || warningCount != 1 {
+		t.Errorf("expected 6 errors and 1 warning but got %d and %d", errorCount, warningCount)
+	}
+}
@@ -1,6 +1,14 @@
 package czid
 
-import "fmt"
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
 
 type validateSampleNamesRequest struct {
 	SampleNames      []string `json:"sample_names"`
@@ -22,3 +30,112 @@ func (c *Client) ValidateSampleNames(sampleNames []string, projectID int) ([]str
 
 	return res, err
 }
+
+// policies for handling sample names that already exist in a project
+const (
+	NameConflictRename = "rename"
+	NameConflictSkip   = "skip"
+	NameConflictFail   = "fail"
+)
+
+var NameConflictPolicies = []string{NameConflictRename, NameConflictSkip, NameConflictFail}
+
+// NamePlan describes how sample names that already exist in a project are
+// handled. Renamed maps original names to new names, Skipped lists original
+// names that will not be uploaded.
+type NamePlan struct {
+	Renamed map[string]string
+	Skipped []string
+}
+
+// PlanSampleNames compares sample names with the names returned by
+// ValidateSampleNames and applies policy to those that were changed
+func PlanSampleNames(sampleNames []string, newSampleNames []string, policy string) (NamePlan, error) {
+	plan := NamePlan{Renamed: map[string]string{}, Skipped: []string{}}
+	if len(sampleNames) != len(newSampleNames) {
+		return plan, errors.New("error validating sample names")
+	}
+
+	conflicts := []string{}
+	for i, sampleName := range sampleNames {
+		if newSampleNames[i] == sampleName {
+			continue
+		}
+		conflicts = append(conflicts, sampleName)
+		switch policy {
+		case NameConflictRename:
+			plan.Renamed[sampleName] = newSampleNames[i]
+		case NameConflictSkip:
+			plan.Skipped = append(plan.Skipped, sampleName)
+		}
+	}
+	sort.Strings(plan.Skipped)
+
+	if policy == NameConflictFail && len(conflicts) > 0 {
+		sort.Strings(conflicts)
+		return plan, fmt.Errorf("sample names already exist in project: %s", strings.Join(conflicts, ", "))
+	}
+	return plan, nil
+}
+
+func (p NamePlan) friendlyPrint() {
+	if len(p.Renamed) > 0 {
+		originalNames := make([]string, 0, len(p.Renamed))
+		for originalName := range p.Renamed {
+			originalNames = append(originalNames, originalName)
+		}
+		sort.Strings(originalNames)
+		fmt.Println("some sample names already exist in this project, they will be renamed:")
+		for _, originalName := range originalNames {
+			fmt.Printf("  \"%s\" -> \"%s\"\n", originalName, p.Renamed[originalName])
+		}
+	}
+	if len(p.Skipped) > 0 {
+		fmt.Println("some sample names already exist in this project, they will be skipped:")
+		for _, sampleName := range p.Skipped {
+			fmt.Printf("  %s\n", sampleName)
+		}
+	}
+}
+
+// WriteNameMapping writes a CSV mapping each original sample name to the name
+// and ID it was created with. Skipped samples have an empty name and ID.
+func WriteNameMapping(path string, plan NamePlan, originalNames []string, sampleIDs map[string]int) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	skipped := make(map[string]bool, len(plan.Skipped))
+	for _, sampleName := range plan.Skipped {
+		skipped[sampleName] = true
+	}
+
+	sorted := append([]string{}, originalNames...)
+	sort.Strings(sorted)
+
+	writer := csv.NewWriter(f)
+	err = writer.Write([]string{"Original Sample Name", "Sample Name", "Sample ID"})
+	if err != nil {
+		return err
+	}
+	for _, originalName := range sorted {
+		row := []string{originalName, "", ""}
+		if !skipped[originalName] {
+			finalName := originalName
+			if newName, renamed := plan.Renamed[originalName]; renamed {
+				finalName = newName
+			}
+			row[1] = finalName
+			if id, has := sampleIDs[finalName]; has {
+				row[2] = strconv.Itoa(id)
+			}
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -1,6 +1,7 @@
 package czid
 
 import (
+	"os"
 	"testing"
 )
 
@@ -21,3 +22,53 @@ func TestValidateSampleNames(t *testing.T) {
 		t.Errorf("incorrect response %s", sample_names)
 	}
 }
+
+func TestPlanSampleNames(t *testing.T) {
+	sampleNames := []string{"a", "b", "c"}
+	newSampleNames := []string{"a", "b_1", "c_1"}
+
+	plan, err := PlanSampleNames(sampleNames, newSampleNames, NameConflictRename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Renamed) != 2 || plan.Renamed["b"] != "b_1" || len(plan.Skipped) != 0 {
+		t.Errorf("unexpected rename plan %+v", plan)
+	}
+
+	plan, err = PlanSampleNames(sampleNames, newSampleNames, NameConflictSkip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Renamed) != 0 || len(plan.Skipped) != 2 || plan.Skipped[0] != "b" {
+		t.Errorf("unexpected skip plan %+v", plan)
+	}
+
+	_, err = PlanSampleNames(sampleNames, newSampleNames, NameConflictFail)
+	if err == nil || err.Error() != "sample names already exist in project: b, c" {
+		t.Errorf("expected a name conflict error but got %v", err)
+	}
+}
+
+func TestWriteNameMapping(t *testing.T) {
+	f, err := os.CreateTemp("", "*.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	plan := NamePlan{Renamed: map[string]string{"b": "b_1"}, Skipped: []string{"c"}}
+	err = WriteNameMapping(f.Name(), plan, []string{"c", "b", "a"}, map[string]int{"a": 1, "b_1": 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "Original Sample Name,Sample Name,Sample ID\na,a,1\nb,b_1,2\nc,,\n"
+	if string(b) != expected {
+		t.Errorf("expected:\n%s\nbut got:\n%s", expected, string(b))
+	}
+}
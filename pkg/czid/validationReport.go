@@ -0,0 +1,140 @@
+package czid
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// exit codes for metadata validation
+const (
+	// ExitCodeValidationErrors is used when metadata has errors
+	ExitCodeValidationErrors = 1
+	// ExitCodeValidationWarnings is used when metadata only has warnings
+	ExitCodeValidationWarnings = 3
+)
+
+// ErrMetadataValidationFailed is returned when CZ ID's metadata validation
+// finds errors, the errors have already been printed
+var ErrMetadataValidationFailed = errors.New("metadata validation failed")
+
+// ValidationReportFormats are the supported validation report file extensions
+var ValidationReportFormats = []string{".json", ".csv"}
+
+var validationReportHeaders = []string{"Severity", "Caption", "Sample Name", "Column", "Value"}
+
+// CheckValidationReportPath returns an error if path does not have one of
+// the ValidationReportFormats extensions
+func CheckValidationReportPath(path string) error {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, format := range ValidationReportFormats {
+		if ext == format {
+			return nil
+		}
+	}
+	return fmt.Errorf("validation report %s must end with one of: %s", path, strings.Join(ValidationReportFormats, ", "))
+}
+
+// metadataIssues converts an issue from CZ ID's metadata validation to one
+// MetadataIssue per row of the issue. Recognized columns of the rows fill in
+// the sample name, column, and value, other columns are added to the caption.
+func (m validateCSVResIssue) metadataIssues(severity string) []MetadataIssue {
+	if m.StringError != "" {
+		return []MetadataIssue{{Severity: severity, Caption: m.StringError}}
+	}
+	if len(m.DetailedIssue.Rows) == 0 {
+		return []MetadataIssue{{Severity: severity, Caption: m.DetailedIssue.Caption}}
+	}
+
+	issues := make([]MetadataIssue, 0, len(m.DetailedIssue.Rows))
+	for _, row := range m.DetailedIssue.Rows {
+		issue := MetadataIssue{Severity: severity}
+		details := []string{}
+		for i, header := range m.DetailedIssue.Headers {
+			if i >= len(row) {
+				break
+			}
+			normalized := normalizeHeader(header)
+			switch {
+			case normalized == "row #" || normalized == "row":
+				continue
+			case strings.Contains(normalized, "sample name"):
+				issue.SampleName = row[i]
+			case strings.Contains(normalized, "column"):
+				issue.Column = row[i]
+			case strings.Contains(normalized, "value"):
+				issue.Value = row[i]
+			default:
+				details = append(details, fmt.Sprintf("%s: %s", header, row[i]))
+			}
+		}
+		issue.Caption = m.DetailedIssue.Caption
+		if len(details) > 0 {
+			issue.Caption = fmt.Sprintf("%s (%s)", issue.Caption, strings.Join(details, ", "))
+		}
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+// metadataIssues converts all errors and warnings from CZ ID's metadata
+// validation to MetadataIssues
+func (i validateCSVResIssues) metadataIssues() []MetadataIssue {
+	issues := []MetadataIssue{}
+	for _, issue := range i.Errors {
+		issues = append(issues, issue.metadataIssues(IssueError)...)
+	}
+	for _, issue := range i.Warnings {
+		issues = append(issues, issue.metadataIssues(IssueWarning)...)
+	}
+	return issues
+}
+
+// WriteValidationReport writes metadata issues to a JSON or CSV file based
+// on the path's extension
+func WriteValidationReport(path string, issues []MetadataIssue) error {
+	if err := CheckValidationReportPath(path); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		encoder := json.NewEncoder(f)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(issues)
+	}
+
+	w := csv.NewWriter(f)
+	if err := w.Write(validationReportHeaders); err != nil {
+		return err
+	}
+	for _, issue := range issues {
+		err := w.Write([]string{issue.Severity, issue.Caption, issue.SampleName, issue.Column, issue.Value})
+		if err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// ValidationExitCode returns the exit code for metadata issues, 0 if there
+// are no issues
+func ValidationExitCode(issues []MetadataIssue) int {
+	errorCount, warningCount := CountIssues(issues)
+	if errorCount > 0 {
+		return ExitCodeValidationErrors
+	}
+	if warningCount > 0 {
+		return ExitCodeValidationWarnings
+	}
+	return 0
+}
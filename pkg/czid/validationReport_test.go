@@ -0,0 +1,116 @@
+package czid
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var testValidateCSVIssues = []byte(`{
+  "errors": [
+    "a string error",
+    {
+      "caption": "Invalid values for Nucleotide Type",
+      "isGroup": false,
+      "headers": ["Row #", "Sample Name", "Column Name", "Invalid Value", "Reason"],
+      "rows": [[1, "sample one", "Nucleotide Type", "Protein", "not an option"], [2, "sample two", "Nucleotide Type", "Lipid", "not an option"]]
+    }
+  ],
+  "warnings": [
+    {"caption": "Unknown column", "isGroup": false, "headers": ["Column"], "rows": [["Favorite Color"]]}
+  ]
+}`)
+
+func TestValidateCSVMetadataIssues(t *testing.T) {
+	var res validateCSVResIssues
+	if err := json.Unmarshal(testValidateCSVIssues, &res); err != nil {
+		t.Fatal(err)
+	}
+	expected := []MetadataIssue{
+		{Severity: IssueError, Caption: "a string error"},
+		{Severity: IssueError, Caption: "Invalid values for Nucleotide Type (Reason: not an option)", SampleName: "sample one", Column: "Nucleotide Type", Value: "Protein"},
+		{Severity: IssueError, Caption: "Invalid values for Nucleotide Type (Reason: not an option)", SampleName: "sample two", Column: "Nucleotide Type", Value: "Lipid"},
+		{Severity: IssueWarning, Caption: "Unknown column", Column: "Favorite Color"},
+	}
+	issues := res.metadataIssues()
+	if !reflect.DeepEqual(issues, expected) {
+		t.Errorf("expected %+v but got %+v", expected, issues)
+	}
+	if code := ValidationExitCode(issues); code != ExitCodeValidationErrors {
+		t.Errorf("expected exit code %d but got %d", ExitCodeValidationErrors, code)
+	}
+	if code := ValidationExitCode(issues[3:]); code != ExitCodeValidationWarnings {
+		t.Errorf("expected exit code %d for only warnings but got %d", ExitCodeValidationWarnings, code)
+	}
+	if code := ValidationExitCode([]MetadataIssue{}); code != 0 {
+		t.Errorf("expected exit code 0 without issues but got %d", code)
+	}
+}
+
+func TestWriteValidationReport(t *testing.T) {
+	dir := t.TempDir()
+	issues := []MetadataIssue{
+		{Severity: IssueError, Caption: "Ct Value must be a number", SampleName: "sample one", Column: "Ct Value", Value: "high"},
+		{Severity: IssueWarning, Caption: "unknown column", Column: "Favorite Color"},
+	}
+
+	jsonPath := filepath.Join(dir, "report.json")
+	if err := WriteValidationReport(jsonPath, issues); err != nil {
+		t.Fatal(err)
+	}
+	contents, err := os.ReadFile(jsonPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var jsonIssues []MetadataIssue
+	if err := json.Unmarshal(contents, &jsonIssues); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(jsonIssues, issues) {
+		t.Errorf("expected %+v but got %+v", issues, jsonIssues)
+	}
+
+	csvPath := filepath.Join(dir, "report.csv")
+	if err := WriteValidationReport(csvPath, issues); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(csvPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := [][]string{
+		{"Severity", "Caption", "Sample Name", "Column", "Value"},
+		{"error", "Ct Value must be a number", "sample one", "Ct Value", "high"},
+		{"warning", "unknown column", "", "Favorite Color", ""},
+	}
+	if !reflect.DeepEqual(rows, expected) {
+		t.Errorf("expected %v but got %v", expected, rows)
+	}
+
+	if err := WriteValidationReport(filepath.Join(dir, "report.txt"), issues); err == nil {
+		t.Error("expected an error for an unsupported report format")
+	}
+}
+
+func TestValidateProjectMetadataErrors(t *testing.T) {
+	response := append(append([]byte(`{"issues": `), testValidateCSVIssues...), '}')
+	httpClient := newMockHTTPClient(response)
+	apiClient := Client{auth0: &mockAuth0Client{}, httpClient: &httpClient}
+
+	issues, err := apiClient.ValidateProjectMetadata(7, SamplesMetadata{"sample one": NewMetadata(map[string]string{})})
+	if !errors.Is(err, ErrMetadataValidationFailed) {
+		t.Errorf("expected ErrMetadataValidationFailed but got %v", err)
+	}
+	if len(issues) != 4 {
+		t.Errorf("expected the issues to be returned with the error but got %+v", issues)
+	}
+}
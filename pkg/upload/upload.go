@@ -120,18 +120,25 @@ func (u *Uploader) UploadFiles(filenames []string, s3path string, multipartUploa
 		}
 		size += stat.Size()
 	}
-	u.initSize(size)
 
 	readers := make([]io.Reader, len(filenames))
 	for i, filename := range filenames {
-		var err error
-		readers[i], err = os.Open(filename)
+		f, err := os.Open(filename)
 		if err != nil {
 			return err
 		}
+		defer f.Close()
+		readers[i] = f
 	}
 
-	reader := io.MultiReader(readers...)
+	return u.UploadStream(io.MultiReader(readers...), size, strings.Join(filenames, ", "), s3path, multipartUploadId)
+}
+
+// UploadStream uploads the contents of reader to s3path. size is used to tune
+// the part size and for the progress bar, for streams that are generated on
+// the fly (ex. FASTQ converted from BAM) it should be an estimate.
+func (u *Uploader) UploadStream(reader io.Reader, size int64, description string, s3path string, multipartUploadId *string) error {
+	u.initSize(size)
 
 	parsedPath, err := url.Parse(s3path)
 	if err != nil {
@@ -161,7 +168,7 @@ func (u *Uploader) UploadFiles(filenames []string, s3path string, multipartUploa
 			return err
 		}
 	} else {
-		fmt.Printf("skipping upload of %s: already uploaded\n", strings.Join(filenames, ", "))
+		fmt.Printf("skipping upload of %s: already uploaded\n", description)
 		return nil
 	}
 
@@ -171,7 +178,7 @@ func (u *Uploader) UploadFiles(filenames []string, s3path string, multipartUploa
 	go u.runProgressBar(size)
 
 	if multipartUploadId != nil {
-		fmt.Printf("resuming upload of %s\n", strings.Join(filenames, ", "))
+		fmt.Printf("resuming upload of %s\n", description)
 		_, err = u.u.ResumeUpload(context.Background(), &input, multipartUploadId)
 		if err != nil {
 			fmt.Println("could not resume upload, starting fresh upload")
@@ -182,7 +189,7 @@ func (u *Uploader) UploadFiles(filenames []string, s3path string, multipartUploa
 			_, err = u.u.Upload(context.Background(), &input)
 		}
 	} else {
-		fmt.Printf("starting upload of %s\n", strings.Join(filenames, ", "))
+		fmt.Printf("starting upload of %s\n", description)
 		_, err = u.u.Upload(context.Background(), &input)
 	}
 	return err
@@ -1,11 +1,14 @@
 package util
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path"
 	"runtime"
 	"sync"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
@@ -80,6 +83,41 @@ func ViperCache() (*viper.Viper, error) {
 	return v, err
 }
 
+// ReadJSONCache reads the JSON file name from the cache directory into v. It
+// returns false if the file does not exist.
+func ReadJSONCache(name string, v interface{}) (bool, error) {
+	cacheDir, err := GetCacheDir()
+	if err != nil {
+		return false, err
+	}
+	b, err := os.ReadFile(path.Join(cacheDir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, json.Unmarshal(b, v)
+}
+
+// WriteJSONCache writes v as JSON to the file name in the cache directory,
+// creating parent directories as needed
+func WriteJSONCache(name string, v interface{}) error {
+	cacheDir, err := GetCacheDir()
+	if err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	filename := path.Join(cacheDir, name)
+	if err := MkdirIfNotExists(path.Dir(filename)); err != nil {
+		return err
+	}
+	return os.WriteFile(filename, b, 0644)
+}
+
 func Tune() {
 	fmt.Println(runtime.NumCPU())
 	var memStats runtime.MemStats
@@ -103,3 +141,42 @@ func StringMapKeys(m map[string]string) []string {
 	}
 	return keys
 }
+
+// EditDistance returns the Levenshtein distance between two strings
+func EditDistance(a string, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = prev[j-1] + cost
+			if prev[j]+1 < curr[j] {
+				curr[j] = prev[j] + 1
+			}
+			if curr[j-1]+1 < curr[j] {
+				curr[j] = curr[j-1] + 1
+			}
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// FlagAliases returns a flag normalization function that maps each alias to
+// the name of the flag it stands for, ex. FlagAliases(map[string]string{"metadata-file": "metadata-csv"})
+func FlagAliases(aliases map[string]string) func(*pflag.FlagSet, string) pflag.NormalizedName {
+	return func(f *pflag.FlagSet, name string) pflag.NormalizedName {
+		if flagName, has := aliases[name]; has {
+			name = flagName
+		}
+		return pflag.NormalizedName(name)
+	}
+}
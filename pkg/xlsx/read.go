@@ -0,0 +1,335 @@
+package xlsx
+
+// This file is for reading the cell values of .xlsx workbooks as strings
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"math"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type xmlWorkbook struct {
+	WorkbookPr struct {
+		Date1904 string `xml:"date1904,attr"`
+	} `xml:"workbookPr"`
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xmlRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xmlText struct {
+	T string `xml:"t"`
+	R []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xmlText) String() string {
+	if len(t.R) == 0 {
+		return t.T
+	}
+	var b strings.Builder
+	b.WriteString(t.T)
+	for _, r := range t.R {
+		b.WriteString(r.T)
+	}
+	return b.String()
+}
+
+type xmlSharedStrings struct {
+	SI []xmlText `xml:"si"`
+}
+
+type xmlStyles struct {
+	NumFmts []struct {
+		ID   int    `xml:"numFmtId,attr"`
+		Code string `xml:"formatCode,attr"`
+	} `xml:"numFmts>numFmt"`
+	CellXfs []struct {
+		NumFmtID int `xml:"numFmtId,attr"`
+	} `xml:"cellXfs>xf"`
+}
+
+type xmlCell struct {
+	Ref   string  `xml:"r,attr"`
+	Type  string  `xml:"t,attr"`
+	Style int     `xml:"s,attr"`
+	Value string  `xml:"v"`
+	IS    xmlText `xml:"is"`
+}
+
+type xmlWorksheet struct {
+	Rows []struct {
+		Cells []xmlCell `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// Workbook is an opened .xlsx file
+type Workbook struct {
+	zr            *zip.ReadCloser
+	sheetNames    []string
+	sheetPaths    []string
+	sharedStrings []string
+	dateStyles    map[int]bool
+	date1904      bool
+}
+
+func readXML(zr *zip.ReadCloser, name string, v interface{}) (bool, error) {
+	for _, f := range zr.File {
+		if f.Name != name {
+			continue
+		}
+		r, err := f.Open()
+		if err != nil {
+			return true, err
+		}
+		defer r.Close()
+		return true, xml.NewDecoder(r).Decode(v)
+	}
+	return false, nil
+}
+
+// builtInDateFormats are the built in number format IDs that display dates,
+// formats that only display a time are left out
+var builtInDateFormats = map[int]bool{
+	14: true, 15: true, 16: true, 17: true, 22: true,
+	27: true, 30: true, 36: true, 50: true, 57: true,
+}
+
+var quotedOrBracketed = regexp.MustCompile(`"[^"]*"|\[[^\]]*\]|\\.`)
+
+// isDateFormatCode reports whether a custom number format code displays a
+// date. Formats with hours or seconds and no day or year, like h:mm or
+// [h]:mm:ss, only display a time so their m is minutes, not months.
+func isDateFormatCode(code string) bool {
+	code = strings.ToLower(quotedOrBracketed.ReplaceAllString(code, ""))
+	if strings.ContainsAny(code, "dy") {
+		return true
+	}
+	if strings.ContainsAny(code, "hs") {
+		return false
+	}
+	return strings.Contains(code, "m") && !strings.Contains(code, "0")
+}
+
+// Open opens an .xlsx workbook
+func Open(filename string) (*Workbook, error) {
+	zr, err := zip.OpenReader(filename)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a valid .xlsx file: %w", filename, err)
+	}
+	wb := &Workbook{zr: zr, dateStyles: map[int]bool{}}
+
+	var workbook xmlWorkbook
+	if found, err := readXML(zr, "xl/workbook.xml", &workbook); err != nil || !found {
+		zr.Close()
+		if err == nil {
+			err = errors.New("missing xl/workbook.xml")
+		}
+		return nil, fmt.Errorf("%s is not a valid .xlsx file: %w", filename, err)
+	}
+	wb.date1904 = workbook.WorkbookPr.Date1904 == "1" || workbook.WorkbookPr.Date1904 == "true"
+
+	var rels xmlRelationships
+	if _, err := readXML(zr, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		zr.Close()
+		return nil, err
+	}
+	targets := map[string]string{}
+	for _, rel := range rels.Relationships {
+		target := rel.Target
+		if strings.HasPrefix(target, "/") {
+			target = strings.TrimPrefix(target, "/")
+		} else {
+			target = path.Join("xl", target)
+		}
+		targets[rel.ID] = target
+	}
+	for _, sheet := range workbook.Sheets {
+		wb.sheetNames = append(wb.sheetNames, sheet.Name)
+		wb.sheetPaths = append(wb.sheetPaths, targets[sheet.RID])
+	}
+
+	var sharedStrings xmlSharedStrings
+	if _, err := readXML(zr, "xl/sharedStrings.xml", &sharedStrings); err != nil {
+		zr.Close()
+		return nil, err
+	}
+	for _, si := range sharedStrings.SI {
+		wb.sharedStrings = append(wb.sharedStrings, si.String())
+	}
+
+	var styles xmlStyles
+	if _, err := readXML(zr, "xl/styles.xml", &styles); err != nil {
+		zr.Close()
+		return nil, err
+	}
+	customDateFormats := map[int]bool{}
+	for _, numFmt := range styles.NumFmts {
+		customDateFormats[numFmt.ID] = isDateFormatCode(numFmt.Code)
+	}
+	for i, xf := range styles.CellXfs {
+		wb.dateStyles[i] = builtInDateFormats[xf.NumFmtID] || customDateFormats[xf.NumFmtID]
+	}
+	return wb, nil
+}
+
+// Close closes the workbook's file
+func (wb *Workbook) Close() error {
+	return wb.zr.Close()
+}
+
+// SheetNames returns the names of the workbook's sheets in order
+func (wb *Workbook) SheetNames() []string {
+	return wb.sheetNames
+}
+
+// sheetIndex finds a sheet by name, or by 1 based index if sheet is a number.
+// An empty sheet selects the first sheet.
+func (wb *Workbook) sheetIndex(sheet string) (int, error) {
+	if len(wb.sheetNames) == 0 {
+		return 0, errors.New("workbook has no sheets")
+	}
+	if sheet == "" {
+		return 0, nil
+	}
+	for i, name := range wb.sheetNames {
+		if name == sheet {
+			return i, nil
+		}
+	}
+	if n, err := strconv.Atoi(sheet); err == nil && n >= 1 && n <= len(wb.sheetNames) {
+		return n - 1, nil
+	}
+	return 0, fmt.Errorf("sheet '%s' not found, sheets: %s", sheet, strings.Join(wb.sheetNames, ", "))
+}
+
+// columnIndex converts the letters of a cell reference like "AB12" to a 0
+// based column index
+func columnIndex(ref string) int {
+	col := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		col = col*26 + int(r-'A') + 1
+	}
+	return col - 1
+}
+
+func (wb *Workbook) excelDate(serial float64) string {
+	epoch := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	if wb.date1904 {
+		epoch = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	days := math.Floor(serial)
+	seconds := math.Round((serial - days) * 24 * 60 * 60)
+	t := epoch.AddDate(0, 0, int(days)).Add(time.Duration(seconds) * time.Second)
+	if seconds == 0 {
+		return t.Format("2006-01-02")
+	}
+	return t.Format("2006-01-02T15:04:05")
+}
+
+func formatNumber(v string) string {
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return v
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func (wb *Workbook) cellValue(c xmlCell) (string, error) {
+	switch c.Type {
+	case "s":
+		i, err := strconv.Atoi(c.Value)
+		if err != nil || i < 0 || i >= len(wb.sharedStrings) {
+			return "", fmt.Errorf("invalid shared string reference in cell %s", c.Ref)
+		}
+		return wb.sharedStrings[i], nil
+	case "inlineStr":
+		return c.IS.String(), nil
+	case "b":
+		if c.Value == "1" {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	case "str", "e":
+		return c.Value, nil
+	default:
+		if c.Value == "" {
+			return "", nil
+		}
+		if wb.dateStyles[c.Style] {
+			if serial, err := strconv.ParseFloat(c.Value, 64); err == nil {
+				return wb.excelDate(serial), nil
+			}
+		}
+		return formatNumber(c.Value), nil
+	}
+}
+
+// ReadRows reads the cell values of a sheet, selected by name or 1 based
+// index, as strings. Numbers formatted as dates are converted to ISO 8601
+// dates. Rows are padded so cells keep their column positions.
+func (wb *Workbook) ReadRows(sheet string) ([][]string, error) {
+	i, err := wb.sheetIndex(sheet)
+	if err != nil {
+		return nil, err
+	}
+
+	var worksheet xmlWorksheet
+	found, err := readXML(wb.zr, wb.sheetPaths[i], &worksheet)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("sheet '%s' is missing from the workbook", wb.sheetNames[i])
+	}
+
+	rows := make([][]string, 0, len(worksheet.Rows))
+	for _, xmlRow := range worksheet.Rows {
+		row := []string{}
+		for j, c := range xmlRow.Cells {
+			col := j
+			if c.Ref != "" {
+				col = columnIndex(c.Ref)
+			}
+			for len(row) <= col {
+				row = append(row, "")
+			}
+			value, err := wb.cellValue(c)
+			if err != nil {
+				return nil, err
+			}
+			row[col] = value
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// ReadFile reads the rows of a sheet from an .xlsx file
+func ReadFile(filename string, sheet string) ([][]string, error) {
+	wb, err := Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer wb.Close()
+	return wb.ReadRows(sheet)
+}
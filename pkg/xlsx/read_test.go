@@ -0,0 +1,145 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"os"
+	"reflect"
+	"testing"
+)
+
+var testWorkbookFiles = map[string]string{
+	"xl/workbook.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Notes" sheetId="1" r:id="rId1"/><sheet name="Metadata" sheetId="2" r:id="rId2"/></sheets>
+</workbook>`,
+	"xl/_rels/workbook.xml.rels": `<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="worksheet" Target="worksheets/sheet1.xml"/>
+<Relationship Id="rId2" Type="worksheet" Target="worksheets/sheet2.xml"/>
+</Relationships>`,
+	"xl/sharedStrings.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<si><t>Sample Name</t></si><si><t>Collection Date</t></si><si><r><t>sample </t></r><r><t>one</t></r></si><si><t>notes</t></si>
+</sst>`,
+	"xl/styles.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<numFmts count="1"><numFmt numFmtId="164" formatCode="yyyy\-mm\-dd"/></numFmts>
+<cellXfs count="3"><xf numFmtId="0"/><xf numFmtId="14"/><xf numFmtId="164"/></cellXfs>
+</styleSheet>`,
+	"xl/worksheets/sheet1.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+<row r="1"><c r="A1" t="s"><v>3</v></c></row>
+</sheetData></worksheet>`,
+	"xl/worksheets/sheet2.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="D1" t="inlineStr"><is><t>Age</t></is></c></row>
+<row r="2"><c r="A2" t="s"><v>2</v></c><c r="B2" s="1"><v>44562</v></c><c r="D2"><v>42.50</v></c></row>
+<row r="3"><c r="A3" t="inlineStr"><is><t>sample two</t></is></c><c r="B3" s="2"><v>44593</v></c><c r="C3" t="b"><v>1</v></c></row>
+</sheetData></worksheet>`,
+}
+
+func createTestWorkbook(t *testing.T) string {
+	f, err := os.CreateTemp("", "*.xlsx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	w := zip.NewWriter(f)
+	for name, contents := range testWorkbookFiles {
+		fw, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := fw.Write([]byte(contents)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return f.Name()
+}
+
+func TestReadFile(t *testing.T) {
+	filename := createTestWorkbook(t)
+	defer os.Remove(filename)
+
+	expected := [][]string{
+		{"Sample Name", "Collection Date", "", "Age"},
+		{"sample one", "2022-01-01", "", "42.5"},
+		{"sample two", "2022-02-01", "TRUE"},
+	}
+	for _, sheet := range []string{"Metadata", "2"} {
+		rows, err := ReadFile(filename, sheet)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(rows, expected) {
+			t.Errorf("sheet %s: expected %v but got %v", sheet, expected, rows)
+		}
+	}
+
+	rows, err := ReadFile(filename, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rows, [][]string{{"notes"}}) {
+		t.Errorf("expected the first sheet to be read by default but got %v", rows)
+	}
+
+	if _, err := ReadFile(filename, "Missing"); err == nil {
+		t.Error("expected an error for a missing sheet")
+	}
+}
+
+func TestIsDateFormatCode(t *testing.T) {
+	tests := map[string]bool{
+		`yyyy\-mm\-dd`:        true,
+		"d-mmm":               true,
+		"mmm yyyy":            true,
+		"mmmm":                true,
+		"m/d/yy h:mm":         true,
+		"h:mm":                false,
+		"[h]:mm:ss":           false,
+		"mm:ss":               false,
+		"h:mm AM/PM":          false,
+		"0.00":                false,
+		`#,##0 "mm"`:          false,
+		"[$-409]mmmm d, yyyy": true,
+	}
+	for code, expected := range tests {
+		if isDate := isDateFormatCode(code); isDate != expected {
+			t.Errorf("expected %v for format %q but got %v", expected, code, isDate)
+		}
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	f, err := os.CreateTemp("", "*.xlsx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	rows := [][]string{
+		{"Sample Name", "Notes"},
+		{"sample one", "<5 & \"quoted\""},
+		{"sample two"},
+	}
+	if err := WriteFile(f.Name(), "Metadata", rows); err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := ReadFile(f.Name(), "Metadata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(read, rows) {
+		t.Errorf("expected %v but got %v", rows, read)
+	}
+
+	if columnName(0) != "A" || columnName(25) != "Z" || columnName(27) != "AB" {
+		t.Errorf("unexpected column names %s %s %s", columnName(0), columnName(25), columnName(27))
+	}
+}